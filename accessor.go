@@ -0,0 +1,46 @@
+package behold
+
+import "darvaza.org/core"
+
+// Accessor is an accessor function registered to build queries on
+// a field of values of type V. Queries built by an Accessor are
+// recognised by it, so stores can serve them using indexes created
+// with the same Accessor, however the function was defined.
+type Accessor[V any, X any] struct {
+	fn func(V) X
+}
+
+// NewAccessor registers an accessor function. Panics if the
+// function is nil.
+func NewAccessor[V any, X any](fn func(V) X) *Accessor[V, X] {
+	if fn == nil {
+		panic(core.NewPanicError(1, "nil accessor function"))
+	}
+	return &Accessor[V, X]{fn: fn}
+}
+
+// Get returns the result of the accessor function for the value.
+func (a *Accessor[V, X]) Get(v V) X {
+	return a.fn(v)
+}
+
+// Query returns a ComposedQuery matching the result of the accessor
+// function against the given query, recognised by the Accessor.
+// Panics if the query is nil.
+func (a *Accessor[V, X]) Query(query Query[X]) Query[V] {
+	if query == nil {
+		panic(core.NewPanicError(1, "nil value query"))
+	}
+
+	return &composeQuery[V, X]{fn: a.fn, query: query, acc: a}
+}
+
+// BaseOf returns the query the result of the accessor function is
+// matched against, if the given query was built by the Accessor.
+func (a *Accessor[V, X]) BaseOf(q Query[V]) (Query[X], bool) {
+	cq, ok := q.(composer[V, X])
+	if !ok || a == nil || cq.composed().acc != a {
+		return nil, false
+	}
+	return cq.composed().query, true
+}
@@ -0,0 +1,40 @@
+package behold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessor(t *testing.T) {
+	div := func(n int) *Accessor[int, int] {
+		return NewAccessor(func(v int) int { return v / n })
+	}
+
+	tenths, thirds := div(10), div(3)
+	assert.Equal(t, 4, tenths.Get(42))
+
+	q := tenths.Query(EqQuery(4))
+	assert.True(t, q.Match(42))
+	assert.False(t, q.Match(50))
+
+	// recognised by the accessor that built it, not by its code
+	base, ok := tenths.BaseOf(q)
+	assert.True(t, ok)
+	assert.Equal(t, "== 4", DescribeQuery(base))
+
+	_, ok = thirds.BaseOf(q)
+	assert.False(t, ok)
+	_, ok = tenths.BaseOf(ComposeQuery(tenths.Get, EqQuery(4)))
+	assert.False(t, ok)
+
+	// combining keeps the queries recognisable
+	lq, ok := q.And(thirds.Query(GtQuery(1))).(LogicalQuery[int])
+	if assert.True(t, ok) {
+		_, ok = tenths.BaseOf(lq.Operands()[0])
+		assert.True(t, ok)
+	}
+
+	assert.Panics(t, func() { NewAccessor[int, int](nil) })
+	assert.Panics(t, func() { tenths.Query(nil) })
+}
@@ -0,0 +1,118 @@
+package behold
+
+import "darvaza.org/core"
+
+// CounterTx is a Tx able to count the entries matching a query by
+// itself, for example using indexes, without visiting their values.
+type CounterTx[K comparable, V any] interface {
+	Tx[K, V]
+
+	// Count returns the number of entries whose value matches any of
+	// the given queries, or the number of entries if none is given.
+	Count(ors ...Query[V]) (int, error)
+}
+
+// Count returns the number of entries whose value matches any of the
+// given queries, or the number of entries if none is given.
+// If the transaction implements CounterTx its Count method is used.
+func Count[K comparable, V any](tx Tx[K, V], ors ...Query[V]) (int, error) {
+	if tx == nil {
+		return 0, ErrNilReceiver
+	}
+
+	if ctx, ok := tx.(CounterTx[K, V]); ok {
+		return ctx.Count(ors...)
+	}
+
+	var n int
+	err := tx.ForEachMatch(func(K, V) bool {
+		n++
+		return true
+	}, ors...)
+	return n, err
+}
+
+// Sum returns the sum of the accessor's result for the entries whose
+// value matches any of the given queries, or every entry if none is given.
+// Strings are concatenated in the store's order.
+func Sum[K comparable, V any, X core.Ordered](tx Tx[K, V], fn func(V) X, ors ...Query[V]) (X, error) {
+	var sum X
+
+	switch {
+	case tx == nil:
+		return sum, ErrNilReceiver
+	case fn == nil:
+		return sum, ErrInvalid
+	}
+
+	err := tx.ForEachMatch(func(_ K, v V) bool {
+		sum += fn(v)
+		return true
+	}, ors...)
+	return sum, err
+}
+
+// MinBy returns the first entry with the lowest value according to cmp
+// among those matching any of the given queries, or every entry if none
+// is given. It returns false if no entry matches.
+func MinBy[K comparable, V any](tx Tx[K, V], cmp CompFunc[V], ors ...Query[V]) (Entry[K, V], bool, error) {
+	return bestBy(tx, cmp, func(c int) bool { return c < 0 }, ors)
+}
+
+// MaxBy returns the first entry with the highest value according to cmp
+// among those matching any of the given queries, or every entry if none
+// is given. It returns false if no entry matches.
+func MaxBy[K comparable, V any](tx Tx[K, V], cmp CompFunc[V], ors ...Query[V]) (Entry[K, V], bool, error) {
+	return bestBy(tx, cmp, func(c int) bool { return c > 0 }, ors)
+}
+
+// bestBy returns the first entry whose comparison with any other
+// matching entry isn't better.
+func bestBy[K comparable, V any](tx Tx[K, V], cmp CompFunc[V], better func(int) bool,
+	ors []Query[V]) (Entry[K, V], bool, error) {
+	var best Entry[K, V]
+	var found bool
+
+	switch {
+	case tx == nil:
+		return best, false, ErrNilReceiver
+	case cmp == nil:
+		return best, false, ErrInvalid
+	}
+
+	err := tx.ForEachMatch(func(k K, v V) bool {
+		if !found || better(cmp(v, best.Value)) {
+			best = Entry[K, V]{Key: k, Value: v}
+			found = true
+		}
+		return true
+	}, ors...)
+	if err != nil {
+		return Entry[K, V]{}, false, err
+	}
+	return best, found, nil
+}
+
+// GroupBy returns the entries whose value matches any of the given
+// queries, or every entry if none is given, grouped by the accessor's
+// result. Entries within a group keep the store's order.
+func GroupBy[K comparable, V any, G comparable](tx Tx[K, V], fn func(V) G,
+	ors ...Query[V]) (map[G][]Entry[K, V], error) {
+	switch {
+	case tx == nil:
+		return nil, ErrNilReceiver
+	case fn == nil:
+		return nil, ErrInvalid
+	}
+
+	out := make(map[G][]Entry[K, V])
+	err := tx.ForEachMatch(func(k K, v V) bool {
+		g := fn(v)
+		out[g] = append(out[g], Entry[K, V]{Key: k, Value: v})
+		return true
+	}, ors...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
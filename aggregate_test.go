@@ -0,0 +1,65 @@
+package behold
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isOdd(v int) bool { return v%2 != 0 }
+
+func TestAggregates(t *testing.T) {
+	tx := &sliceTx{
+		entries: []Entry[string, int]{
+			{"c", 3}, {"a", 1}, {"d", 4}, {"b", 2}, {"e", 4},
+		},
+	}
+
+	n, err := Count[string, int](tx)
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	n, err = Count[string, int](tx, GtQuery(2))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	sum, err := Sum[string, int](tx, func(v int) int { return v * 10 }, LtQuery(3))
+	require.NoError(t, err)
+	assert.Equal(t, 30, sum)
+
+	e, ok, err := MinBy[string, int](tx, cmp.Compare[int])
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Entry[string, int]{"a", 1}, e)
+
+	e, ok, err = MaxBy[string, int](tx, cmp.Compare[int])
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, Entry[string, int]{"d", 4}, e)
+
+	_, ok, err = MaxBy[string, int](tx, cmp.Compare[int], GtQuery(10))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	groups, err := GroupBy[string, int](tx, isOdd)
+	require.NoError(t, err)
+	assert.Equal(t, map[bool][]Entry[string, int]{
+		true:  {{"c", 3}, {"a", 1}},
+		false: {{"d", 4}, {"b", 2}, {"e", 4}},
+	}, groups)
+
+	errBroken := errors.New("broken")
+	tx.err = errBroken
+	_, err = Count[string, int](tx)
+	assert.ErrorIs(t, err, errBroken)
+	_, err = GroupBy[string, int](tx, isOdd)
+	assert.ErrorIs(t, err, errBroken)
+
+	_, err = Sum[string, int, int](tx, nil)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = Count[string, int](nil)
+	assert.ErrorIs(t, err, ErrNilReceiver)
+}
@@ -0,0 +1,84 @@
+package behold
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+)
+
+// Codec converts values of type T to and from bytes, allowing
+// persistent stores to keep typed keys and values.
+type Codec[T any] interface {
+	// Marshal encodes a value.
+	Marshal(T) ([]byte, error)
+
+	// Unmarshal decodes a value previously encoded by Marshal.
+	Unmarshal([]byte) (T, error)
+}
+
+// interface assertions
+var _ Codec[any] = JSONCodec[any]{}
+var _ Codec[any] = GobCodec[any]{}
+var _ Codec[[]byte] = BytesCodec[[]byte]{}
+var _ Codec[string] = StringCodec[string]{}
+
+// JSONCodec is a Codec using encoding/json.
+type JSONCodec[T any] struct{}
+
+// Marshal encodes a value as JSON.
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes a JSON encoded value.
+func (JSONCodec[T]) Unmarshal(b []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+// GobCodec is a Codec using encoding/gob. Each value is encoded
+// independently, including its type information.
+type GobCodec[T any] struct{}
+
+// Marshal encodes a value using gob.
+func (GobCodec[T]) Marshal(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a gob encoded value.
+func (GobCodec[T]) Unmarshal(b []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return v, err
+}
+
+// BytesCodec is a Codec for byte slices, stored as they are.
+type BytesCodec[T ~[]byte] struct{}
+
+// Marshal returns a copy of the value.
+func (BytesCodec[T]) Marshal(v T) ([]byte, error) {
+	return bytes.Clone(v), nil
+}
+
+// Unmarshal returns a copy of the data.
+func (BytesCodec[T]) Unmarshal(b []byte) (T, error) {
+	return T(bytes.Clone(b)), nil
+}
+
+// StringCodec is a Codec for strings, stored as they are.
+type StringCodec[T ~string] struct{}
+
+// Marshal returns the bytes of the string.
+func (StringCodec[T]) Marshal(v T) ([]byte, error) {
+	return []byte(v), nil
+}
+
+// Unmarshal returns the data as a string.
+func (StringCodec[T]) Unmarshal(b []byte) (T, error) {
+	return T(b), nil
+}
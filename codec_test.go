@@ -0,0 +1,56 @@
+package behold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testID string
+
+func testCodecRoundTrip[T any](t *testing.T, codec Codec[T], values ...T) {
+	t.Helper()
+
+	for _, v := range values {
+		b, err := codec.Marshal(v)
+		require.NoError(t, err)
+
+		v2, err := codec.Unmarshal(b)
+		require.NoError(t, err)
+		assert.Equal(t, v, v2)
+	}
+}
+
+func TestJSONCodec(t *testing.T) {
+	testCodecRoundTrip(t, JSONCodec[testPerson]{},
+		testPerson{Name: nameJohn, Age: 25},
+		testPerson{})
+	testCodecRoundTrip(t, JSONCodec[int]{}, 0, -1, 42)
+
+	_, err := JSONCodec[int]{}.Unmarshal([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestGobCodec(t *testing.T) {
+	testCodecRoundTrip(t, GobCodec[testPerson]{},
+		testPerson{Name: nameAlice, Age: 30},
+		testPerson{})
+	testCodecRoundTrip(t, GobCodec[string]{}, "", nameBob)
+
+	_, err := GobCodec[int]{}.Unmarshal([]byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestRawCodecs(t *testing.T) {
+	testCodecRoundTrip(t, StringCodec[string]{}, "", nameJohn)
+	testCodecRoundTrip(t, StringCodec[testID]{}, testID("id"))
+	testCodecRoundTrip(t, BytesCodec[[]byte]{}, []byte("data"))
+
+	// the bytes are copied
+	data := []byte("data")
+	b, err := BytesCodec[[]byte]{}.Marshal(data)
+	require.NoError(t, err)
+	b[0] = 'D'
+	assert.Equal(t, []byte("data"), data)
+}
@@ -0,0 +1,197 @@
+package behold
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+
+	"darvaza.org/core"
+)
+
+// Cursor marks where a paginated iteration stopped, as the last key
+// visited and the version of the data it was read from.
+type Cursor[K comparable] struct {
+	Key     K
+	Version uint64
+}
+
+// Encode returns the cursor as an opaque URL-safe token, using the
+// codec to encode the key.
+func (c *Cursor[K]) Encode(codec Codec[K]) (string, error) {
+	switch {
+	case c == nil:
+		return "", ErrNilReceiver
+	case codec == nil:
+		return "", ErrInvalid
+	}
+
+	key, err := codec.Marshal(c.Key)
+	if err != nil {
+		return "", err
+	}
+
+	buf := binary.AppendUvarint(nil, c.Version)
+	buf = append(buf, key...)
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// DecodeCursor parses a token created by Cursor.Encode, using the
+// codec to decode the key. Malformed tokens fail with ErrInvalid.
+func DecodeCursor[K comparable](token string, codec Codec[K]) (*Cursor[K], error) {
+	if codec == nil {
+		return nil, ErrInvalid
+	}
+
+	buf, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, core.Wrap(ErrInvalid, "malformed cursor")
+	}
+
+	version, n := binary.Uvarint(buf)
+	if n <= 0 {
+		return nil, core.Wrap(ErrInvalid, "malformed cursor")
+	}
+
+	key, err := codec.Unmarshal(buf[n:])
+	if err != nil {
+		return nil, core.Wrap(ErrInvalid, "malformed cursor key")
+	}
+
+	return &Cursor[K]{Key: key, Version: version}, nil
+}
+
+// PageOptions controls where Page starts and how many entries
+// it visits.
+type PageOptions[K comparable] struct {
+	// After is the cursor returned by the previous page, or nil
+	// to start from the first entry.
+	After *Cursor[K]
+
+	// Limit is the maximum number of entries to visit,
+	// which must be positive.
+	Limit int
+}
+
+// Validate checks the options are valid.
+func (opts PageOptions[K]) Validate() error {
+	if opts.Limit <= 0 {
+		return core.Wrap(ErrInvalid, "non-positive limit")
+	}
+	return nil
+}
+
+// Page calls fn for up to opts.Limit entries whose value matches any of
+// the given queries, or every entry if none is given, in the store's
+// order, until fn returns false. Iteration starts after opts.After, or
+// from the first entry if nil.
+//
+// It returns the cursor to continue from, or nil if there are no more
+// entries. Pages following the first are read from the same version
+// of the data, so concurrent updates don't shift them. If the store
+// doesn't keep that version anymore, Page fails with ErrSnapshotExpired.
+// Stores implementing SnapshotStore can keep past versions, others
+// only while they aren't updated.
+func Page[K comparable, V any](ctx context.Context, s Store[K, V], opts PageOptions[K],
+	fn func(key K, value V) bool, ors ...Query[V]) (*Cursor[K], error) {
+	switch {
+	case s == nil:
+		return nil, ErrNilReceiver
+	case ctx == nil, fn == nil:
+		return nil, ErrInvalid
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	after := opts.After
+	var next *Cursor[K]
+	view := func(tx Tx[K, V]) error {
+		if after != nil && tx.Version() != after.Version {
+			return ErrSnapshotExpired
+		}
+
+		p := pager[K, V]{fn: fn, limit: opts.Limit}
+		if err := p.run(tx, after, ors); err != nil {
+			return err
+		}
+
+		if p.more && p.count > 0 {
+			next = &Cursor[K]{Key: p.last, Version: tx.Version()}
+		}
+		return nil
+	}
+
+	var err error
+	if ss, ok := s.(SnapshotStore[K, V]); ok && after != nil {
+		err = ss.ViewAt(ctx, after.Version, view)
+	} else {
+		err = s.View(ctx, view)
+	}
+	return next, err
+}
+
+// pager visits the entries of a page.
+type pager[K comparable, V any] struct {
+	fn    func(K, V) bool
+	limit int
+	count int
+	last  K
+	more  bool
+}
+
+// visit passes an entry to fn if the page isn't full yet.
+func (p *pager[K, V]) visit(key K, value V) bool {
+	if p.count == p.limit {
+		p.more = true
+		return false
+	}
+
+	p.count++
+	p.last = key
+	if !p.fn(key, value) {
+		p.more = true
+		return false
+	}
+	return true
+}
+
+// run visits the entries following the cursor.
+func (p *pager[K, V]) run(tx Tx[K, V], after *Cursor[K], ors []Query[V]) error {
+	if after == nil {
+		return tx.ForEachMatch(p.visit, ors...)
+	}
+
+	match := func(V) bool { return true }
+	if len(ors) > 0 {
+		match = MatchAny(ors...).Match
+	}
+
+	if otx, ok := tx.(OrderedTx[K, V]); ok {
+		err := otx.Seek(after.Key, func(k K, v V) bool {
+			if k == after.Key || !match(v) {
+				return true
+			}
+			return p.visit(k, v)
+		})
+
+		// ErrInvalid tells the store isn't sorted by key after all
+		if !errors.Is(err, ErrInvalid) {
+			return err
+		}
+	}
+
+	found := false
+	return tx.ForEachMatch(func(k K, v V) bool {
+		switch {
+		case !found:
+			found = k == after.Key
+			return true
+		case !match(v):
+			return true
+		default:
+			return p.visit(k, v)
+		}
+	})
+}
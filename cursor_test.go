@@ -0,0 +1,38 @@
+package behold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorEncode(t *testing.T) {
+	codec := StringCodec[string]{}
+
+	c := &Cursor[string]{Key: "some/key", Version: 300}
+	token, err := c.Encode(codec)
+	require.NoError(t, err)
+	assert.NotContains(t, token, "/")
+
+	got, err := DecodeCursor(token, codec)
+	require.NoError(t, err)
+	assert.Equal(t, c, got)
+
+	for _, token := range []string{"", "not base64!", "gA"} {
+		_, err := DecodeCursor(token, codec)
+		assert.ErrorIs(t, err, ErrInvalid, "token %q", token)
+	}
+
+	_, err = (*Cursor[string])(nil).Encode(codec)
+	assert.ErrorIs(t, err, ErrNilReceiver)
+	_, err = c.Encode(nil)
+	assert.ErrorIs(t, err, ErrInvalid)
+}
+
+func TestPageOptions(t *testing.T) {
+	assert.NoError(t, PageOptions[string]{Limit: 1}.Validate())
+	for _, limit := range []int{0, -1} {
+		assert.ErrorIs(t, PageOptions[string]{Limit: limit}.Validate(), ErrInvalid)
+	}
+}
@@ -0,0 +1,32 @@
+package behold
+
+// Entry is a key-value pair, allowing queries to consider
+// both the key and the value of an entry.
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// EntryKey returns the key of an Entry. It can be used as
+// accessor function in ComposeQuery.
+func EntryKey[K comparable, V any](e Entry[K, V]) K {
+	return e.Key
+}
+
+// EntryValue returns the value of an Entry. It can be used as
+// accessor function in ComposeQuery.
+func EntryValue[K comparable, V any](e Entry[K, V]) V {
+	return e.Value
+}
+
+// KeyQuery creates an Entry Query matching the key of entries
+// against the given query. Panics if the query is nil.
+func KeyQuery[K comparable, V any](query Query[K]) Query[Entry[K, V]] {
+	return ComposeQuery(EntryKey[K, V], query)
+}
+
+// ValueQuery creates an Entry Query matching the value of entries
+// against the given query. Panics if the query is nil.
+func ValueQuery[K comparable, V any](query Query[V]) Query[Entry[K, V]] {
+	return ComposeQuery(EntryValue[K, V], query)
+}
@@ -0,0 +1,33 @@
+package behold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryQueries(t *testing.T) {
+	john := Entry[string, testPerson]{
+		Key:   "john",
+		Value: testPerson{Name: nameJohn, Age: 25},
+	}
+	bob := Entry[string, testPerson]{
+		Key:   "bob",
+		Value: testPerson{Name: nameBob, Age: 10},
+	}
+
+	byKey := KeyQuery[string, testPerson](EqQuery("john"))
+	assert.True(t, byKey.Match(john))
+	assert.False(t, byKey.Match(bob))
+
+	isAdult := ValueQuery[string](ComposeQuery(personAge, GtEqQuery(18)))
+	assert.True(t, isAdult.Match(john))
+	assert.False(t, isAdult.Match(bob))
+
+	either := byKey.Or(KeyQuery[string, testPerson](EqQuery("bob")))
+	assert.True(t, either.Match(john))
+	assert.True(t, either.Match(bob))
+
+	assert.Panics(t, func() { KeyQuery[string, testPerson](nil) })
+	assert.Panics(t, func() { ValueQuery[string, testPerson](nil) })
+}
@@ -2,6 +2,7 @@ package behold
 
 import (
 	"errors"
+	"fmt"
 
 	"darvaza.org/core"
 )
@@ -12,8 +13,57 @@ var ErrNilReceiver = core.ErrNilReceiver
 // ErrInvalid is an error indicating an invalid state or argument
 var ErrInvalid = core.ErrInvalid
 
+// ErrNotFound is an error indicating the requested key doesn't exist
+var ErrNotFound = core.ErrNotExists
+
 // ErrClosed is an error indicating that an object or resource is closed and cannot be used
 var ErrClosed = errors.New("closed")
 
 // ErrReadOnlyTx is an error indicating an attempt to modify a read-only transaction
 var ErrReadOnlyTx = errors.New("read-only transaction")
+
+// ErrSnapshotExpired is an error indicating the data version requested
+// is no longer kept by the store
+var ErrSnapshotExpired = errors.New("snapshot no longer available")
+
+// ErrConflict is an error indicating a key was changed by another
+// transaction, invalidating the ones that read it
+var ErrConflict = errors.New("conflicting change")
+
+// KeyError is an error related to a particular key.
+// It wraps the cause, so errors.Is can be used to
+// check for it.
+type KeyError[K comparable] struct {
+	Key K
+	Err error
+}
+
+// NewKeyError creates a KeyError for the given key and cause.
+func NewKeyError[K comparable](key K, err error) *KeyError[K] {
+	return &KeyError[K]{Key: key, Err: err}
+}
+
+// NewNotFoundError creates a KeyError indicating the given key
+// doesn't exist.
+func NewNotFoundError[K comparable](key K) *KeyError[K] {
+	return NewKeyError(key, ErrNotFound)
+}
+
+// NewConflictError creates a KeyError indicating the given key was
+// changed by another transaction.
+func NewConflictError[K comparable](key K) *KeyError[K] {
+	return NewKeyError(key, ErrConflict)
+}
+
+// Error returns the description of the error, including the key.
+func (e *KeyError[K]) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%v: %s", e.Key, ErrInvalid)
+	}
+	return fmt.Sprintf("%v: %s", e.Key, e.Err)
+}
+
+// Unwrap returns the cause of the error.
+func (e *KeyError[K]) Unwrap() error {
+	return e.Err
+}
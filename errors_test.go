@@ -0,0 +1,30 @@
+package behold
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyError(t *testing.T) {
+	err := error(NewNotFoundError(nameJohn))
+
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, "John: does not exist", err.Error())
+
+	var ke *KeyError[string]
+	if assert.True(t, errors.As(err, &ke)) {
+		assert.Equal(t, nameJohn, ke.Key)
+	}
+
+	// different key types don't match
+	var ke2 *KeyError[int]
+	assert.False(t, errors.As(err, &ke2))
+
+	err = NewKeyError(42, ErrInvalid)
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	assert.Equal(t, "42: invalid argument", (&KeyError[int]{Key: 42}).Error())
+}
@@ -0,0 +1,194 @@
+package behold
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"darvaza.org/core"
+)
+
+// Logical operators of an Expr.
+const (
+	ExprAnd = "and"
+	ExprOr  = "or"
+	ExprNot = "not"
+	ExprXor = "xor"
+)
+
+// Expr is a declarative description of a Query, which can be encoded
+// as JSON and compiled back into a Query using the fields of a Schema.
+//
+// Comparisons set Op to the symbol of a CompareOp, like "==" or ">=",
+// and name the Field compared against the Value. Logical expressions
+// set Op to ExprAnd, ExprOr, ExprXor or ExprNot, and combine the expressions in
+// Args, of which ExprNot takes exactly one.
+type Expr struct {
+	Op    string  `json:"op"`
+	Field string  `json:"field,omitempty"`
+	Value any     `json:"value,omitempty"`
+	Args  []*Expr `json:"args,omitempty"`
+}
+
+// UnmarshalJSON decodes an Expr, keeping numeric values as json.Number
+// so they can be converted to the type of their field without losing
+// precision.
+func (e *Expr) UnmarshalJSON(data []byte) error {
+	type plain Expr
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode((*plain)(e))
+}
+
+// Compile builds the Query described by the expression.
+// Invalid expressions fail with ErrInvalid.
+func (s *Schema[V]) Compile(e *Expr) (Query[V], error) {
+	switch {
+	case s == nil:
+		return nil, ErrNilReceiver
+	case e == nil:
+		return nil, core.Wrap(ErrInvalid, "nil expression")
+	}
+
+	switch e.Op {
+	case ExprAnd, ExprOr, ExprXor:
+		return s.compileLogical(e)
+	case ExprNot:
+		if len(e.Args) != 1 {
+			return nil, core.Wrapf(ErrInvalid, "%q takes one argument, got %v", e.Op, len(e.Args))
+		}
+
+		q, err := s.Compile(e.Args[0])
+		if err != nil {
+			return nil, err
+		}
+		return Not(q), nil
+	default:
+		return s.compileComparison(e)
+	}
+}
+
+func (s *Schema[V]) compileLogical(e *Expr) (Query[V], error) {
+	queries := make([]Query[V], 0, len(e.Args))
+	for _, arg := range e.Args {
+		q, err := s.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+
+	switch e.Op {
+	case ExprAnd:
+		return MatchAll(queries...), nil
+	case ExprXor:
+		return Xor(queries...), nil
+	default:
+		return MatchAny(queries...), nil
+	}
+}
+
+func (s *Schema[V]) compileComparison(e *Expr) (Query[V], error) {
+	op, ok := ParseCompareOp(e.Op)
+	if !ok {
+		return nil, core.Wrapf(ErrInvalid, "unknown operator %q", e.Op)
+	}
+
+	f, ok := s.Field(e.Field)
+	if !ok {
+		return nil, core.Wrapf(ErrInvalid, "unknown field %q", e.Field)
+	}
+
+	value, err := exprValue(e.Value)
+	if err != nil {
+		return nil, err
+	}
+	return f.Query(op, value)
+}
+
+// exprValue converts decoded JSON numbers to int64, uint64 or float64,
+// whichever fits first.
+func exprValue(v any) (any, error) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return v, nil
+	}
+
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u, nil
+	}
+	if f, err := n.Float64(); err == nil {
+		return f, nil
+	}
+	return nil, core.Wrapf(ErrInvalid, "invalid number %s", n)
+}
+
+// Expr returns the declarative description of a query, which must be
+// built from comparisons made by the schema's fields, combined using
+// MatchAll, MatchAny, MatchNone, Not, Xor, And and Or. Other queries
+// fail with ErrInvalid.
+func (s *Schema[V]) Expr(q Query[V]) (*Expr, error) {
+	if s == nil {
+		return nil, ErrNilReceiver
+	}
+
+	switch v := q.(type) {
+	case nil:
+		return nil, core.Wrap(ErrInvalid, "nil query")
+	case LogicalQuery[V]:
+		return s.logicalExpr(v)
+	default:
+		return s.comparisonExpr(q)
+	}
+}
+
+func (s *Schema[V]) logicalExpr(q LogicalQuery[V]) (*Expr, error) {
+	var op string
+	switch q.Operator() {
+	case OpAnd:
+		op = ExprAnd
+	case OpOr:
+		op = ExprOr
+	case OpXor:
+		op = ExprXor
+	case OpNot:
+		op = ExprNot
+	default:
+		return nil, core.Wrapf(ErrInvalid, "unsupported operator %s", q.Operator())
+	}
+
+	out := &Expr{Op: op}
+	for _, arg := range q.Operands() {
+		if arg == nil && op != ExprNot {
+			// ignored when matching
+			continue
+		}
+
+		e, err := s.Expr(arg)
+		if err != nil {
+			return nil, err
+		}
+		out.Args = append(out.Args, e)
+	}
+	return out, nil
+}
+
+func (s *Schema[V]) comparisonExpr(q Query[V]) (*Expr, error) {
+	for _, name := range s.Fields() {
+		f, ok := s.fields[name].(fieldComparer[V])
+		if !ok {
+			continue
+		}
+
+		if op, operand, ok := f.comparison(q); ok {
+			return &Expr{Op: op.String(), Field: name, Value: operand}, nil
+		}
+	}
+
+	return nil, core.Wrapf(ErrInvalid, "query %s isn't a comparison of a field",
+		DescribeQuery(q))
+}
@@ -0,0 +1,127 @@
+package behold
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSchema(t *testing.T) *Schema[testPerson] {
+	t.Helper()
+
+	s := NewSchema[testPerson]()
+	require.NoError(t, AddField(s, "age", personAge))
+	require.NoError(t, AddField(s, "name", personName))
+	return s
+}
+
+// buildQuery builds a comparison using a field of the schema.
+func buildQuery(t *testing.T, s *Schema[testPerson], name string,
+	op CompareOp, operand any) Query[testPerson] {
+	t.Helper()
+
+	f, ok := s.Field(name)
+	require.True(t, ok)
+	q, err := f.Query(op, operand)
+	require.NoError(t, err)
+	return q
+}
+
+func TestExprRoundTrip(t *testing.T) {
+	s := newTestSchema(t)
+	isAdult := buildQuery(t, s, "age", OpGtEq, 18)
+	isJohn := buildQuery(t, s, "name", OpEq, nameJohn)
+
+	for _, tc := range []struct {
+		q    Query[testPerson]
+		json string
+	}{
+		{isAdult, `{"op":">=","field":"age","value":18}`},
+		{buildQuery(t, s, "age", OpLtEq, 0), `{"op":"<=","field":"age","value":0}`},
+		{isAdult.And(isJohn.Or(buildQuery(t, s, "name", OpNotEq, nameBob))),
+			`{"op":"and","args":[{"op":">=","field":"age","value":18},` +
+				`{"op":"or","args":[{"op":"==","field":"name","value":"John"},` +
+				`{"op":"!=","field":"name","value":"Bob"}]}]}`},
+		{Not(buildQuery(t, s, "age", OpGt, 65)),
+			`{"op":"not","args":[{"op":">","field":"age","value":65}]}`},
+		{Xor(isAdult, isJohn),
+			`{"op":"xor","args":[{"op":">=","field":"age","value":18},` +
+				`{"op":"==","field":"name","value":"John"}]}`},
+		{MatchNone(isJohn),
+			`{"op":"not","args":[{"op":"or","args":[{"op":"==","field":"name","value":"John"}]}]}`},
+		{MatchAny[testPerson](), `{"op":"or"}`},
+	} {
+		t.Run(tc.json, func(t *testing.T) {
+			e, err := s.Expr(tc.q)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(e)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.json, string(data))
+
+			var decoded Expr
+			require.NoError(t, json.Unmarshal(data, &decoded))
+
+			q, err := s.Compile(&decoded)
+			require.NoError(t, err)
+			assert.Equal(t, DescribeQuery(tc.q), DescribeQuery(q))
+
+			for _, p := range []testPerson{
+				{nameJohn, 30}, {nameAlice, 17}, {nameBob, 70}, {nameBob, 0},
+			} {
+				assert.Equal(t, tc.q.Match(p), q.Match(p), "%v", p)
+			}
+		})
+	}
+}
+
+func TestExprErrors(t *testing.T) {
+	s := newTestSchema(t)
+
+	for _, data := range []string{
+		`{"op":"~","field":"age","value":1}`,
+		`{"op":"==","field":"height","value":1}`,
+		`{"op":"==","field":"age","value":"old"}`,
+		`{"op":"==","field":"age","value":1.5}`,
+		`{"op":"not","args":[]}`,
+		`{"op":"and","args":[{"op":"==","field":"age"}]}`,
+		`{"op":"or","args":[null]}`,
+	} {
+		var e Expr
+		require.NoError(t, json.Unmarshal([]byte(data), &e))
+
+		_, err := s.Compile(&e)
+		assert.ErrorIs(t, err, ErrInvalid, data)
+	}
+
+	for _, q := range []Query[testPerson]{
+		nil,
+		Not[testPerson](nil),
+		QueryFunc[testPerson](func(p testPerson) bool { return p.Age > 1 }),
+		ComposeQuery(func(p testPerson) int { return p.Age }, EqQuery(1)),
+		ComposeQuery(personAge, EqQueryFn(1, func(a, b int) int { return a - b })),
+		// same accessor, but not built by the field
+		ComposeQuery(personAge, EqQuery(1)),
+	} {
+		_, err := s.Expr(q)
+		assert.ErrorIs(t, err, ErrInvalid)
+	}
+}
+
+func TestExprClosures(t *testing.T) {
+	div := func(n int) func(testPerson) int {
+		return func(p testPerson) int { return p.Age / n }
+	}
+
+	s := NewSchema[testPerson]()
+	require.NoError(t, AddField(s, "tens", div(10)))
+	require.NoError(t, AddField(s, "thirds", div(3)))
+
+	for _, name := range []string{"tens", "thirds"} {
+		e, err := s.Expr(buildQuery(t, s, name, OpEq, 2))
+		require.NoError(t, err)
+		assert.Equal(t, name, e.Field)
+	}
+}
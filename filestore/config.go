@@ -0,0 +1,106 @@
+package filestore
+
+import (
+	"time"
+
+	"github.com/amery/behold"
+)
+
+// SyncPolicy determines when the write-ahead log is flushed to disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways flushes the log before every Commit returns.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval flushes the log periodically, as set by
+	// Config.SyncInterval. A crash may lose the transactions
+	// committed since the last flush.
+	SyncInterval
+	// SyncNever leaves flushing the log to the operating system.
+	SyncNever
+)
+
+// DefaultSyncInterval is the flushing period used by SyncInterval
+// when Config.SyncInterval isn't set.
+const DefaultSyncInterval = time.Second
+
+// Config describes how a Store is opened.
+type Config[K comparable, V any] struct {
+	// Path is the location of the write-ahead log file.
+	// It's created if it doesn't exist.
+	Path string
+
+	// Sync is the flushing policy of the write-ahead log.
+	Sync SyncPolicy
+
+	// SyncInterval is the flushing period when using SyncInterval.
+	SyncInterval time.Duration
+
+	// KeyCodec encodes the keys in the log.
+	// If nil, behold.GobCodec is used.
+	KeyCodec behold.Codec[K]
+
+	// ValueCodec encodes the values in the log.
+	// If nil, behold.GobCodec is used.
+	ValueCodec behold.Codec[V]
+
+	// Now returns the time reference of the store and its transactions.
+	// If nil, time.Now is used.
+	Now func() time.Time
+
+	// Append combines the current value of a key with a new one
+	// when Tx.Append is called on an existing key.
+	// If nil, appending to an existing key fails with ErrInvalid.
+	Append func(key K, current, value V) (V, error)
+
+	// KeyOrder sorts the keys of the store, enabling range scans.
+	// If nil, entries are kept in the order they were first stored.
+	KeyOrder behold.CompFunc[K]
+
+	// Retain is the number of past versions kept available to
+	// ViewAt besides those still used by open transactions.
+	// Past versions aren't persisted.
+	Retain uint64
+
+	// OnLeak, if set, is called with the transactions started by Begin
+	// that stay open longer than LeakThreshold, or are garbage-collected
+	// without being ended. The stack of every Begin is captured for it.
+	OnLeak func(behold.TxLeak)
+
+	// LeakThreshold is how long a transaction started by Begin can stay
+	// open before being reported to OnLeak. If zero, only transactions
+	// garbage-collected are reported.
+	LeakThreshold time.Duration
+}
+
+// validate checks the Config, returning a copy with the
+// defaults filled in.
+func (cfg *Config[K, V]) validate() (*Config[K, V], error) {
+	switch {
+	case cfg.Path == "":
+		return nil, behold.ErrInvalid
+	case cfg.Sync < SyncAlways, cfg.Sync > SyncNever:
+		return nil, behold.ErrInvalid
+	case cfg.SyncInterval < 0:
+		return nil, behold.ErrInvalid
+	}
+
+	out := *cfg
+	if out.Sync == SyncInterval && out.SyncInterval == 0 {
+		out.SyncInterval = DefaultSyncInterval
+	}
+	if out.KeyCodec == nil {
+		out.KeyCodec = behold.GobCodec[K]{}
+	}
+	if out.ValueCodec == nil {
+		out.ValueCodec = behold.GobCodec[V]{}
+	}
+	return &out, nil
+}
+
+// Open opens the Store at the given path using the default
+// configuration.
+func Open[K comparable, V any](path string) (*Store[K, V], error) {
+	cfg := &Config[K, V]{Path: path}
+	return cfg.Open()
+}
@@ -0,0 +1,4 @@
+// Package filestore provides a durable behold.Store keeping its data
+// in memory and recording every committed transaction in a write-ahead
+// log, which is replayed when the store is opened.
+package filestore
@@ -0,0 +1,30 @@
+package filestore
+
+import (
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+	"github.com/amery/behold/memstore"
+)
+
+// AddIndex registers a secondary index on a Store over the values
+// returned by the Accessor. Indexes aren't persisted, so
+// they need to be added every time the store is opened.
+// See memstore.AddIndex for details.
+func AddIndex[K comparable, V any, X core.Ordered](s *Store[K, V], name string, a *behold.Accessor[V, X]) error {
+	if s == nil {
+		return behold.ErrNilReceiver
+	}
+	return memstore.AddIndex(s.mem, name, a)
+}
+
+// AddReverseIndex registers a secondary index like AddIndex, but
+// ordered by descending values.
+// See memstore.AddReverseIndex for details.
+func AddReverseIndex[K comparable, V any, X core.Ordered](s *Store[K, V], name string,
+	a *behold.Accessor[V, X]) error {
+	if s == nil {
+		return behold.ErrNilReceiver
+	}
+	return memstore.AddReverseIndex(s.mem, name, a)
+}
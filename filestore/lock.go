@@ -0,0 +1,54 @@
+package filestore
+
+import (
+	"context"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+)
+
+var _ behold.ContextMutex = writeLock(nil)
+
+// writeLock is a Mutex made of a channel with a single slot, so
+// writers waiting for it can give up when their context ends.
+type writeLock chan struct{}
+
+func newWriteLock() writeLock {
+	return make(writeLock, 1)
+}
+
+// Lock acquires the lock, waiting for it if needed.
+func (l writeLock) Lock() {
+	l <- struct{}{}
+}
+
+// TryLock acquires the lock if it's free, and tells if it did.
+func (l writeLock) TryLock() bool {
+	select {
+	case l <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// LockContext acquires the lock, giving up with the context's
+// error if it ends first.
+func (l writeLock) LockContext(ctx context.Context) error {
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Unlock releases the lock. Panics if it isn't held.
+func (l writeLock) Unlock() {
+	select {
+	case <-l:
+	default:
+		panic(core.NewPanicError(1, "unlock of unlocked write lock"))
+	}
+}
@@ -0,0 +1,377 @@
+package filestore
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+	"github.com/amery/behold/memstore"
+)
+
+// interface assertions
+var _ behold.SnapshotStore[string, any] = (*Store[string, any])(nil)
+var _ behold.BeginStore[string, any] = (*Store[string, any])(nil)
+
+// Store is a durable behold.Store. Data is served from memory, and
+// every committed Update is appended to a write-ahead log before
+// becoming visible.
+type Store[K comparable, V any] struct {
+	mem   *memstore.Store[K, V]
+	codec walCodec[K, V]
+
+	// wmu serialises read-write transactions and protects
+	// the log file.
+	wmu    writeLock
+	f      *os.File
+	size   int64
+	policy SyncPolicy
+	dirty  bool
+	closed bool
+
+	// failed is set when a partial record couldn't be
+	// removed from the log, refusing any further writes.
+	failed error
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Open opens the Store, replaying its write-ahead log to recover
+// the last committed version. Incomplete records at the end of the
+// log, left by a crash while writing, are truncated.
+func (cfg *Config[K, V]) Open() (*Store[K, V], error) {
+	if cfg == nil {
+		return nil, behold.ErrInvalid
+	}
+
+	cfg, err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(cfg.Path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	mcfg := &memstore.Config[K, V]{
+		Now:      cfg.Now,
+		Append:   cfg.Append,
+		KeyOrder: cfg.KeyOrder,
+		Retain:   cfg.Retain,
+
+		OnLeak:        cfg.OnLeak,
+		LeakThreshold: cfg.LeakThreshold,
+	}
+
+	s := &Store[K, V]{
+		mem:    mcfg.New(),
+		wmu:    newWriteLock(),
+		f:      f,
+		policy: cfg.Sync,
+		codec: walCodec[K, V]{
+			key:   cfg.KeyCodec,
+			value: cfg.ValueCodec,
+		},
+	}
+
+	if err := s.recover(); err != nil {
+		_ = s.mem.Close()
+		_ = f.Close()
+		return nil, err
+	}
+
+	if cfg.Sync == SyncInterval {
+		s.spawnSyncer(cfg.SyncInterval)
+	}
+
+	return s, nil
+}
+
+// recover replays the log into memory, truncating a torn tail.
+// Damaged records elsewhere fail with ErrCorrupt, leaving the
+// log untouched.
+func (s *Store[K, V]) recover() error {
+	var offset int64
+
+	info, err := s.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(s.f)
+	for {
+		payload, err := readRecord(r, info.Size()-offset)
+		switch {
+		case err == io.EOF:
+			s.size = offset
+			return nil
+		case err == errTorn:
+			return s.truncate(offset)
+		case err != nil:
+			return core.Wrapf(err, "offset %v", offset)
+		}
+
+		if err := s.replay(payload); err != nil {
+			return err
+		}
+
+		offset += int64(headerSize + len(payload))
+	}
+}
+
+func (s *Store[K, V]) replay(payload []byte) error {
+	var rec walRecord[K, V]
+
+	if err := s.codec.unmarshal(&rec, payload); err != nil {
+		return err
+	}
+
+	if rec.version != s.mem.Version()+1 {
+		return core.Wrapf(ErrCorrupt, "unexpected version %v", rec.version)
+	}
+
+	return s.mem.Update(context.Background(), func(tx behold.Tx[K, V]) error {
+		for _, o := range rec.ops {
+			if err := o.apply(tx); err != nil {
+				return core.Wrap(ErrCorrupt, err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+// apply replays the change. Deleting a missing key is a no-op, as
+// logs written by older versions could contain such deletions.
+func (o *op[K, V]) apply(tx behold.Tx[K, V]) error {
+	if !o.deleted {
+		return tx.Set(o.key, o.value)
+	}
+
+	if err := tx.Delete(o.key); err != nil && !errors.Is(err, behold.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func (s *Store[K, V]) truncate(offset int64) error {
+	if err := s.f.Truncate(offset); err != nil {
+		return err
+	}
+
+	if _, err := s.f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	s.size = offset
+	return s.f.Sync()
+}
+
+// Version returns the version of the last committed Update.
+func (s *Store[K, V]) Version() uint64 {
+	if s == nil {
+		return 0
+	}
+	return s.mem.Version()
+}
+
+// Now returns the store's current time reference.
+func (s *Store[K, V]) Now() time.Time {
+	if s == nil {
+		return time.Now()
+	}
+	return s.mem.Now()
+}
+
+// View executes a read-only transaction, holding the given locks
+// while fn runs.
+func (s *Store[K, V]) View(ctx context.Context, fn func(behold.Tx[K, V]) error, locks ...behold.Mutex) error {
+	if s == nil {
+		return behold.ErrNilReceiver
+	}
+	return s.mem.View(ctx, fn, locks...)
+}
+
+// ViewAt executes a read-only transaction on the data as of the given
+// version, holding the given locks while fn runs. Past versions are
+// available while open transactions use them, and for as many versions
+// as Config.Retain says, failing with ErrSnapshotExpired otherwise.
+func (s *Store[K, V]) ViewAt(ctx context.Context, version uint64, fn func(behold.Tx[K, V]) error,
+	locks ...behold.Mutex) error {
+	if s == nil {
+		return behold.ErrNilReceiver
+	}
+	return s.mem.ViewAt(ctx, version, fn, locks...)
+}
+
+// Update executes a read-write transaction, holding the given locks
+// while fn runs. Changes are logged and committed if fn returns nil
+// without having closed the transaction, and discarded otherwise.
+// It gives up waiting for other writers or the locks if the context
+// ends first.
+func (s *Store[K, V]) Update(ctx context.Context, fn func(behold.Tx[K, V]) error, locks ...behold.Mutex) error {
+	switch {
+	case s == nil:
+		return behold.ErrNilReceiver
+	case ctx == nil, fn == nil:
+		return behold.ErrInvalid
+	}
+
+	if err := behold.LockContext(ctx, s.wmu); err != nil {
+		return err
+	}
+	defer s.wmu.Unlock()
+
+	if s.closed {
+		return behold.ErrClosed
+	}
+
+	return s.mem.Update(ctx, func(mtx behold.Tx[K, V]) error {
+		tx := s.newTx(mtx)
+		defer tx.release()
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		return tx.commitIfOpen()
+	}, locks...)
+}
+
+// Begin starts a transaction, read-write if writable is set, holding
+// the given locks until it's committed or closed. Read-write
+// transactions are serialised, so one not ended blocks further
+// Updates, and Close, until garbage-collected.
+func (s *Store[K, V]) Begin(ctx context.Context, writable bool, locks ...behold.Mutex) (behold.Tx[K, V], error) {
+	if s == nil {
+		return nil, behold.ErrNilReceiver
+	}
+
+	if !writable {
+		return s.mem.Begin(ctx, false, locks...)
+	}
+
+	// the memstore releases wmu when the transaction ends
+	locks = append([]behold.Mutex{s.wmu}, locks...)
+	mtx, err := s.mem.Begin(ctx, true, locks...)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.closed {
+		_ = mtx.Close()
+		return nil, behold.ErrClosed
+	}
+
+	return s.newTx(mtx), nil
+}
+
+// Close flushes and closes the write-ahead log and the store.
+// Any further View or Update will fail with ErrClosed.
+func (s *Store[K, V]) Close() error {
+	if s == nil {
+		return behold.ErrNilReceiver
+	}
+
+	s.wmu.Lock()
+	if s.closed {
+		s.wmu.Unlock()
+		return behold.ErrClosed
+	}
+	s.closed = true
+
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wmu.Unlock()
+
+	s.wg.Wait()
+
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	return core.CoalesceError(
+		s.mem.Close(),
+		s.f.Sync(),
+		s.f.Close(),
+	)
+}
+
+// write appends a record to the log, flushing it according
+// to the policy. s.wmu must be held.
+func (s *Store[K, V]) write(rec *walRecord[K, V]) error {
+	if s.failed != nil {
+		return s.failed
+	}
+
+	b, err := s.codec.marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.f.Write(b); err != nil {
+		// don't leave a partial record behind
+		return core.CoalesceError(s.rollback(s.size), err)
+	}
+
+	if s.policy == SyncAlways {
+		if err := s.f.Sync(); err != nil {
+			return core.CoalesceError(s.rollback(s.size), err)
+		}
+	} else {
+		s.dirty = true
+	}
+
+	s.size += int64(len(b))
+	return nil
+}
+
+// rollback truncates the log back to the given size, removing
+// records that won't be committed. If that fails the log can't be
+// trusted anymore, and further writes are refused. s.wmu must be held.
+func (s *Store[K, V]) rollback(size int64) error {
+	if err := s.truncate(size); err != nil {
+		s.failed = core.Wrap(err, "write-ahead log rollback failed")
+		return s.failed
+	}
+	return nil
+}
+
+func (s *Store[K, V]) spawnSyncer(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.syncDirty()
+			}
+		}
+	}()
+}
+
+func (s *Store[K, V]) syncDirty() {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	if s.dirty && !s.closed {
+		s.dirty = false
+		_ = s.f.Sync()
+	}
+}
@@ -0,0 +1,519 @@
+package filestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"darvaza.org/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+	"github.com/amery/behold/storetest"
+)
+
+func testPath(t *testing.T) string {
+	t.Helper()
+	return filepath.Join(t.TempDir(), "store.wal")
+}
+
+func setAll(t *testing.T, s *Store[string, int], values map[string]int) {
+	t.Helper()
+
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		for k, v := range values {
+			if err := tx.Set(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func getAll(t *testing.T, s *Store[string, int]) map[string]int {
+	t.Helper()
+
+	out := make(map[string]int)
+	err := s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		return tx.ForEach(func(k string, v int) bool {
+			out[k] = v
+			return true
+		})
+	})
+	require.NoError(t, err)
+	return out
+}
+
+func TestRecover(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+
+	setAll(t, s, map[string]int{"one": 1, "two": 2})
+	setAll(t, s, map[string]int{"three": 3})
+
+	err = s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("two", 20))
+		return tx.Delete("one")
+	})
+	require.NoError(t, err)
+
+	// discarded
+	err = s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("four", 4))
+		return tx.Close()
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(3), s.Version())
+	require.NoError(t, s.Close())
+
+	s, err = Open[string, int](path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, uint64(3), s.Version())
+	assert.Equal(t, map[string]int{"two": 20, "three": 3}, getAll(t, s))
+}
+
+func TestRecoverCreatedAndDeleted(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"one": 1})
+
+	err = s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("two", 2))
+		require.NoError(t, tx.Delete("two"))
+		require.NoError(t, tx.Set("one", 10))
+		return tx.Delete("one")
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = Open[string, int](path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, uint64(2), s.Version())
+	assert.Empty(t, getAll(t, s))
+}
+
+func TestRecoverTornTail(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"one": 1})
+	setAll(t, s, map[string]int{"two": 2})
+	require.NoError(t, s.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	for _, cut := range []int64{1, headerSize / 2, headerSize + 1} {
+		require.NoError(t, os.Truncate(path, info.Size()-cut))
+
+		s, err = Open[string, int](path)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), s.Version())
+		assert.Equal(t, map[string]int{"one": 1}, getAll(t, s))
+
+		// new records follow the last good one
+		setAll(t, s, map[string]int{"two": 2})
+		require.NoError(t, s.Close())
+
+		s, err = Open[string, int](path)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), s.Version())
+		require.NoError(t, s.Close())
+	}
+}
+
+func TestRecoverChecksum(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"one": 1})
+	setAll(t, s, map[string]int{"two": 2})
+	require.NoError(t, s.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	b[len(b)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, b, 0o644))
+
+	s, err = Open[string, int](path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, uint64(1), s.Version())
+	assert.Equal(t, map[string]int{"one": 1}, getAll(t, s))
+}
+
+func TestRecoverCorrupt(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"one": 1})
+	setAll(t, s, map[string]int{"two": 2})
+	require.NoError(t, s.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	b[headerSize] ^= 0xff
+	require.NoError(t, os.WriteFile(path, b, 0o644))
+
+	_, err = Open[string, int](path)
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	// the records after it are kept
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, b, after)
+}
+
+func TestRecoverCorruptLength(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"one": 1})
+	setAll(t, s, map[string]int{"two": 2})
+	require.NoError(t, s.Close())
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	b[0] ^= 0xff
+	require.NoError(t, os.WriteFile(path, b, 0o644))
+
+	_, err = Open[string, int](path)
+	assert.ErrorIs(t, err, ErrCorrupt)
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, b, after)
+}
+
+func TestWriteFailed(t *testing.T) {
+	s, err := Open[string, int](testPath(t))
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"one": 1})
+
+	// neither writing nor rolling back can succeed
+	require.NoError(t, s.f.Close())
+
+	fn := func(tx behold.Tx[string, int]) error { return tx.Set("two", 2) }
+	assert.Error(t, s.Update(context.Background(), fn))
+	require.Error(t, s.failed)
+	assert.ErrorIs(t, s.Update(context.Background(), fn), s.failed)
+
+	assert.Equal(t, uint64(1), s.Version())
+	assert.Equal(t, map[string]int{"one": 1}, getAll(t, s))
+}
+
+func TestSyncPolicies(t *testing.T) {
+	for _, policy := range []SyncPolicy{SyncAlways, SyncInterval, SyncNever} {
+		cfg := &Config[string, int]{
+			Path: testPath(t),
+			Sync: policy,
+		}
+
+		s, err := cfg.Open()
+		require.NoError(t, err)
+		setAll(t, s, map[string]int{"one": 1})
+		require.NoError(t, s.Close())
+
+		// defaults aren't written into the Config
+		assert.Zero(t, cfg.SyncInterval)
+		assert.Nil(t, cfg.KeyCodec)
+		assert.Nil(t, cfg.ValueCodec)
+
+		s, err = cfg.Open()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"one": 1}, getAll(t, s))
+		require.NoError(t, s.Close())
+	}
+
+	_, err := (&Config[string, int]{Path: testPath(t), Sync: -1}).Open()
+	assert.ErrorIs(t, err, core.ErrInvalid)
+}
+
+func TestAppendLogged(t *testing.T) {
+	cfg := &Config[string, int]{
+		Path:   testPath(t),
+		Append: func(_ string, a, b int) (int, error) { return a + b, nil },
+	}
+
+	s, err := cfg.Open()
+	require.NoError(t, err)
+
+	for i := 1; i <= 3; i++ {
+		err = s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+			return tx.Append("sum", i)
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, s.Close())
+
+	// replaying doesn't need the Append function
+	s, err = Open[string, int](cfg.Path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, map[string]int{"sum": 6}, getAll(t, s))
+}
+
+func TestClosed(t *testing.T) {
+	s, err := Open[string, int](testPath(t))
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Close(), behold.ErrClosed)
+
+	fn := func(behold.Tx[string, int]) error { return nil }
+	assert.ErrorIs(t, s.View(context.Background(), fn), behold.ErrClosed)
+	assert.ErrorIs(t, s.Update(context.Background(), fn), behold.ErrClosed)
+}
+
+type testPerson struct {
+	Name string
+	Age  int
+}
+
+func TestCodecs(t *testing.T) {
+	cfg := &Config[string, testPerson]{
+		Path:       testPath(t),
+		KeyCodec:   behold.StringCodec[string]{},
+		ValueCodec: behold.JSONCodec[testPerson]{},
+	}
+
+	s, err := cfg.Open()
+	require.NoError(t, err)
+
+	john := testPerson{Name: "John", Age: 25}
+	err = s.Update(context.Background(), func(tx behold.Tx[string, testPerson]) error {
+		return tx.Set("john", john)
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	b, err := os.ReadFile(cfg.Path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `{"Name":"John","Age":25}`)
+
+	s, err = cfg.Open()
+	require.NoError(t, err)
+	defer s.Close()
+
+	err = s.View(context.Background(), func(tx behold.Tx[string, testPerson]) error {
+		v, err := tx.Get("john")
+		assert.NoError(t, err)
+		assert.Equal(t, john, v)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func negate(v int) int { return -v }
+
+var byNegated = behold.NewAccessor(negate)
+
+func TestAddIndex(t *testing.T) {
+	s, err := Open[string, int](testPath(t))
+	require.NoError(t, err)
+	defer s.Close()
+
+	setAll(t, s, map[string]int{"one": 1, "two": 2, "three": 3})
+	require.NoError(t, AddIndex(s, "negated", byNegated))
+	assert.ErrorIs(t, AddIndex(s, "negated", byNegated), core.ErrExists)
+
+	var keys []string
+	err = s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		return tx.ForEachMatch(func(k string, _ int) bool {
+			keys = append(keys, k)
+			return true
+		}, byNegated.Query(behold.LtQuery(-1)))
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"two", "three"}, keys)
+}
+
+func TestConformance(t *testing.T) {
+	storetest.RunStoreTests(t, func(t *testing.T) behold.Store[string, int] {
+		s, err := Open[string, int](testPath(t))
+		require.NoError(t, err)
+		return s
+	})
+}
+
+func TestSetIfVersionLogged(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"a": 1})
+
+	err = s.Update(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.VersionedTx[string, int])
+		if err := tx.SetIfVersion("a", 2, 2); !assert.ErrorIs(t, err, behold.ErrConflict) {
+			return err
+		}
+		return tx.SetIfVersion("a", 3, 1)
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = Open[string, int](path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, map[string]int{"a": 3}, getAll(t, s))
+}
+
+func TestSavepointLogged(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+	setAll(t, s, map[string]int{"a": 1})
+
+	err = s.Update(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.SavepointTx[string, int])
+
+		sp, err := tx.Savepoint()
+		require.NoError(t, err)
+		require.NoError(t, tx.Set("a", 2))
+		require.NoError(t, tx.Set("b", 2))
+		require.NoError(t, tx.RollbackTo(sp))
+		require.NoError(t, tx.Set("c", 3))
+
+		// released changes are kept, and nothing to undo remains
+		require.NoError(t, behold.Nested(btx, func(tx behold.Tx[string, int]) error {
+			return tx.Set("d", 4)
+		}))
+		require.NoError(t, tx.Release(sp))
+		assert.Empty(t, btx.(*Tx[string, int]).saves)
+		assert.Nil(t, btx.(*Tx[string, int]).undo)
+		return tx.Set("e", 5)
+	})
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	s, err = Open[string, int](path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, map[string]int{"a": 1, "c": 3, "d": 4, "e": 5}, getAll(t, s))
+}
+
+func TestBegin(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+
+	tx, err := s.Begin(context.Background(), true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Set("a", 1))
+
+	// writers are serialised
+	assert.False(t, s.wmu.TryLock())
+	require.NoError(t, tx.Commit())
+	assert.True(t, s.wmu.TryLock())
+	s.wmu.Unlock()
+	assert.Panics(t, s.wmu.Unlock)
+
+	tx, err = s.Begin(context.Background(), true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Set("b", 2))
+	require.NoError(t, tx.Close())
+	require.NoError(t, s.Close())
+
+	_, err = s.Begin(context.Background(), true)
+	assert.ErrorIs(t, err, behold.ErrClosed)
+
+	s, err = Open[string, int](path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, map[string]int{"a": 1}, getAll(t, s))
+}
+
+func TestBeginCollected(t *testing.T) {
+	s, err := Open[string, int](testPath(t))
+	require.NoError(t, err)
+
+	func() {
+		tx, err := s.Begin(context.Background(), true)
+		require.NoError(t, err)
+		require.NoError(t, tx.Set("a", 1))
+	}()
+
+	// the dropped writer doesn't block Close
+	done := make(chan error, 1)
+	go func() { done <- s.Close() }()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+			return
+		case <-deadline:
+			t.Fatal("Close blocked by a collected transaction")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestUpdateContext(t *testing.T) {
+	path := testPath(t)
+
+	s, err := Open[string, int](path)
+	require.NoError(t, err)
+
+	// waiting for another writer
+	tx, err := s.Begin(context.Background(), true)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = s.Update(ctx, func(tx behold.Tx[string, int]) error { return tx.Set("a", 1) })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// released while waiting
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		assert.NoError(t, tx.Close())
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	assert.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error { return tx.Set("a", 1) }))
+
+	// cancelled before committing isn't logged
+	ctx, cancel = context.WithCancel(context.Background())
+	err = s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("b", 2))
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	setAll(t, s, map[string]int{"c": 3})
+	require.NoError(t, s.Close())
+
+	s, err = Open[string, int](path)
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.Equal(t, map[string]int{"a": 1, "c": 3}, getAll(t, s))
+}
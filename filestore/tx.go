@@ -0,0 +1,279 @@
+package filestore
+
+import (
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+	"github.com/amery/behold/memstore"
+)
+
+// interface assertions
+var _ behold.VersionedTx[string, any] = (*Tx[string, any])(nil)
+var _ behold.SavepointTx[string, any] = (*Tx[string, any])(nil)
+
+// Tx is a read-write transaction on a filestore Store. It records the
+// changes made through it so they can be logged when committed.
+// Read-only transactions are served directly by the memstore.
+type Tx[K comparable, V any] struct {
+	*memstore.Tx[K, V]
+
+	s       *Store[K, V]
+	changes map[K]int
+	ops     []op[K, V]
+	done    bool
+
+	saves []savepoint
+	undo  []undo[K, V]
+}
+
+// savepoint is the state of the logged changes of a Tx
+// when a behold.Savepoint was marked.
+type savepoint struct {
+	id   behold.Savepoint
+	undo int
+	ops  int
+}
+
+// undo is the logged change of a key before being replaced
+// while savepoints are marked.
+type undo[K comparable, V any] struct {
+	prev op[K, V]
+	had  bool
+}
+
+func (s *Store[K, V]) newTx(mtx behold.Tx[K, V]) *Tx[K, V] {
+	return &Tx[K, V]{
+		Tx:      mtx.(*memstore.Tx[K, V]),
+		s:       s,
+		changes: make(map[K]int),
+	}
+}
+
+// Set associates a value with a key.
+func (tx *Tx[K, V]) Set(key K, value V) error {
+	if err := tx.Tx.Set(key, value); err != nil {
+		return err
+	}
+
+	tx.record(op[K, V]{key: key, value: value})
+	return nil
+}
+
+// SetIfVersion associates a value with a key if the last committed
+// change to the key was at the given version, or if the key didn't
+// exist when the version is 0.
+func (tx *Tx[K, V]) SetIfVersion(key K, value V, version uint64) error {
+	if err := tx.Tx.SetIfVersion(key, value, version); err != nil {
+		return err
+	}
+
+	tx.record(op[K, V]{key: key, value: value})
+	return nil
+}
+
+// Append combines a value with the current one of the key using
+// the store's Append function. If the key doesn't exist, Append
+// behaves like Set.
+func (tx *Tx[K, V]) Append(key K, value V) error {
+	if err := tx.Tx.Append(key, value); err != nil {
+		return err
+	}
+
+	// log the result, so replaying doesn't depend on
+	// the Append function
+	value, err := tx.Tx.Get(key)
+	if err != nil {
+		return err
+	}
+
+	tx.record(op[K, V]{key: key, value: value})
+	return nil
+}
+
+// Delete removes a key and its value.
+func (tx *Tx[K, V]) Delete(key K) error {
+	if err := tx.Tx.Delete(key); err != nil {
+		return err
+	}
+
+	tx.record(op[K, V]{key: key, deleted: true})
+	return nil
+}
+
+// Commit logs the changes of the transaction, applies them to the
+// store and closes it.
+func (tx *Tx[K, V]) Commit() error {
+	switch {
+	case tx == nil:
+		return behold.ErrNilReceiver
+	case tx.done:
+		return behold.ErrClosed
+	default:
+		return tx.commitIfOpen()
+	}
+}
+
+// Close discards any pending change and closes the transaction.
+func (tx *Tx[K, V]) Close() error {
+	if tx == nil {
+		return behold.ErrNilReceiver
+	}
+
+	tx.release()
+	return tx.Tx.Close()
+}
+
+// Savepoint marks the current state of the pending changes, so
+// RollbackTo can discard those made afterwards.
+func (tx *Tx[K, V]) Savepoint() (behold.Savepoint, error) {
+	sp, err := tx.Tx.Savepoint()
+	if err != nil {
+		return 0, err
+	}
+
+	tx.saves = append(tx.saves, savepoint{
+		id:   sp,
+		undo: len(tx.undo),
+		ops:  len(tx.ops),
+	})
+	return sp, nil
+}
+
+// RollbackTo discards the changes made after the savepoint, so they
+// are neither applied nor logged, and forgets the savepoints marked
+// after it.
+func (tx *Tx[K, V]) RollbackTo(sp behold.Savepoint) error {
+	if err := tx.Tx.RollbackTo(sp); err != nil {
+		return err
+	}
+
+	i := tx.findSave(sp)
+	save := tx.saves[i]
+	for j := len(tx.undo) - 1; j >= save.undo; j-- {
+		u := tx.undo[j]
+		if u.had {
+			tx.ops[tx.changes[u.prev.key]] = u.prev
+		} else {
+			delete(tx.changes, u.prev.key)
+		}
+	}
+
+	tx.undo = tx.undo[:save.undo]
+	tx.ops = tx.ops[:save.ops]
+	tx.saves = tx.saves[:i+1]
+	return nil
+}
+
+// Release forgets the savepoint and those marked after it, keeping
+// the changes made since, which will be logged.
+func (tx *Tx[K, V]) Release(sp behold.Savepoint) error {
+	if err := tx.Tx.Release(sp); err != nil {
+		return err
+	}
+
+	i := tx.findSave(sp)
+	tx.saves = tx.saves[:i]
+	if i == 0 {
+		tx.undo = nil
+	}
+	return nil
+}
+
+// findSave returns the position of a savepoint already
+// validated by the memstore.
+func (tx *Tx[K, V]) findSave(sp behold.Savepoint) int {
+	i := len(tx.saves) - 1
+	for i > 0 && tx.saves[i].id != sp {
+		i--
+	}
+	return i
+}
+
+// record stores the last change of a key, remembering if the
+// key was created by the transaction.
+func (tx *Tx[K, V]) record(o op[K, V]) {
+	i, had := tx.changes[o.key]
+	switch {
+	case had:
+		o.fresh = tx.ops[i].fresh
+	case !o.deleted:
+		o.fresh = tx.isNew(o.key)
+	}
+
+	if len(tx.saves) > 0 {
+		u := undo[K, V]{prev: op[K, V]{key: o.key}, had: had}
+		if had {
+			u.prev = tx.ops[i]
+		}
+		tx.undo = append(tx.undo, u)
+	}
+
+	if had {
+		tx.ops[i] = o
+		return
+	}
+
+	tx.changes[o.key] = len(tx.ops)
+	tx.ops = append(tx.ops, o)
+}
+
+// isNew tells if the key had no committed value when the
+// transaction started.
+func (tx *Tx[K, V]) isNew(key K) bool {
+	_, version, err := tx.Tx.GetWithVersion(key)
+	return err == nil && version == 0
+}
+
+// logged returns the changes to write to the log, skipping the keys
+// created and deleted by the transaction.
+func (tx *Tx[K, V]) logged() []op[K, V] {
+	out := make([]op[K, V], 0, len(tx.ops))
+	for _, o := range tx.ops {
+		if !o.fresh || !o.deleted {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// commitIfOpen writes the changes to the log and, once they are
+// there, applies them to memory.
+func (tx *Tx[K, V]) commitIfOpen() error {
+	if tx.done {
+		return nil
+	}
+	defer tx.release()
+
+	if err := tx.Context().Err(); err != nil {
+		// rolled back before logging anything
+		_ = tx.Tx.Close()
+		return err
+	}
+
+	size := tx.s.size
+	rec := &walRecord[K, V]{
+		version: tx.Version() + 1,
+		ops:     tx.logged(),
+	}
+
+	if err := tx.s.write(rec); err != nil {
+		// make sure the changes aren't committed
+		// by the memstore when Update returns.
+		_ = tx.Tx.Close()
+		return err
+	}
+
+	if err := tx.Tx.Commit(); err != nil {
+		// don't replay what wasn't committed
+		return core.CoalesceError(err, tx.s.rollback(size))
+	}
+	return nil
+}
+
+func (tx *Tx[K, V]) release() {
+	tx.done = true
+	tx.changes = nil
+	tx.ops = nil
+	tx.saves = nil
+	tx.undo = nil
+}
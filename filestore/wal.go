@@ -0,0 +1,235 @@
+package filestore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+)
+
+// Each record of the write-ahead log is framed as
+//
+//	| length uint32 | length crc32c uint32 | crc32c uint32 | payload |
+//
+// where the length has its own checksum, so a damaged length isn't
+// mistaken for a record running past the end of the log.
+//
+// and the payload of a record is
+//
+//	| version uvarint | count uvarint | op... |
+//
+// where each op is
+//
+//	| kind byte | key length uvarint | key | [value length uvarint | value] |
+//
+// with the value only present when setting a key.
+const (
+	headerSize    = 12
+	maxRecordSize = 1 << 30
+
+	opSet    byte = 1
+	opDelete byte = 2
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTorn indicates a record was only partially written.
+var errTorn = errors.New("torn record")
+
+// ErrCorrupt indicates the write-ahead log contains records that
+// can't be replayed.
+var ErrCorrupt = errors.New("corrupt write-ahead log")
+
+// op is a change of a key recorded in the write-ahead log.
+// fresh marks keys created by the transaction, and isn't logged.
+type op[K comparable, V any] struct {
+	key     K
+	value   V
+	deleted bool
+	fresh   bool
+}
+
+// walRecord is a committed transaction.
+type walRecord[K comparable, V any] struct {
+	version uint64
+	ops     []op[K, V]
+}
+
+// walCodec encodes and decodes the records of the log.
+type walCodec[K comparable, V any] struct {
+	key   behold.Codec[K]
+	value behold.Codec[V]
+}
+
+func (c *walCodec[K, V]) marshal(r *walRecord[K, V]) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.Write(make([]byte, headerSize))
+	buf.Write(binary.AppendUvarint(nil, r.version))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(r.ops))))
+
+	for i := range r.ops {
+		if err := c.marshalOp(&buf, &r.ops[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	b := buf.Bytes()
+	payload := b[headerSize:]
+	if len(payload) > maxRecordSize {
+		return nil, core.Wrap(core.ErrInvalid, "record too large")
+	}
+
+	binary.LittleEndian.PutUint32(b[0:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(b[4:], crc32.Checksum(b[0:4], crcTable))
+	binary.LittleEndian.PutUint32(b[8:], crc32.Checksum(payload, crcTable))
+	return b, nil
+}
+
+func (c *walCodec[K, V]) marshalOp(buf *bytes.Buffer, o *op[K, V]) error {
+	key, err := c.key.Marshal(o.key)
+	if err != nil {
+		return err
+	}
+
+	kind := opSet
+	if o.deleted {
+		kind = opDelete
+	}
+
+	buf.WriteByte(kind)
+	writeBytes(buf, key)
+
+	if !o.deleted {
+		value, err := c.value.Marshal(o.value)
+		if err != nil {
+			return err
+		}
+		writeBytes(buf, value)
+	}
+	return nil
+}
+
+func (c *walCodec[K, V]) unmarshal(r *walRecord[K, V], payload []byte) error {
+	br := bytes.NewReader(payload)
+
+	version, err := binary.ReadUvarint(br)
+	if err != nil {
+		return ErrCorrupt
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil || count > uint64(len(payload)) {
+		return ErrCorrupt
+	}
+
+	r.version = version
+	r.ops = make([]op[K, V], count)
+	for i := range r.ops {
+		if err := c.unmarshalOp(br, &r.ops[i]); err != nil {
+			return err
+		}
+	}
+
+	if br.Len() > 0 {
+		return ErrCorrupt
+	}
+	return nil
+}
+
+func (c *walCodec[K, V]) unmarshalOp(br *bytes.Reader, o *op[K, V]) error {
+	kind, err := br.ReadByte()
+	if err != nil || (kind != opSet && kind != opDelete) {
+		return ErrCorrupt
+	}
+
+	key, err := readBytes(br)
+	if err != nil {
+		return err
+	}
+	o.key, err = c.key.Unmarshal(key)
+	if err != nil {
+		return core.Wrap(ErrCorrupt, err.Error())
+	}
+
+	o.deleted = kind == opDelete
+	if o.deleted {
+		return nil
+	}
+
+	value, err := readBytes(br)
+	if err != nil {
+		return err
+	}
+	o.value, err = c.value.Unmarshal(value)
+	if err != nil {
+		return core.Wrap(ErrCorrupt, err.Error())
+	}
+	return nil
+}
+
+// readRecord reads the payload of the next record of the log, given
+// the bytes remaining in it. It returns io.EOF at the clean end of the
+// log, and errTorn if the last record is incomplete or fails its
+// checksum. Bad records followed by others, and damaged lengths,
+// are ErrCorrupt.
+func readRecord(r *bufio.Reader, remaining int64) ([]byte, error) {
+	var header [headerSize]byte
+
+	switch _, err := io.ReadFull(r, header[:]); {
+	case err == io.EOF:
+		return nil, io.EOF
+	case err == io.ErrUnexpectedEOF:
+		return nil, errTorn
+	case err != nil:
+		return nil, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[0:])
+	sum := binary.LittleEndian.Uint32(header[8:])
+	last := int64(headerSize) + int64(size)
+	switch {
+	case crc32.Checksum(header[0:4], crcTable) != binary.LittleEndian.Uint32(header[4:]):
+		return nil, core.Wrap(ErrCorrupt, "length checksum mismatch")
+	case size > maxRecordSize:
+		return nil, core.Wrap(ErrCorrupt, "record too large")
+	case last > remaining:
+		return nil, errTorn
+	}
+
+	payload := make([]byte, size)
+	switch _, err := io.ReadFull(r, payload); {
+	case err == io.EOF, err == io.ErrUnexpectedEOF:
+		return nil, errTorn
+	case err != nil:
+		return nil, err
+	case crc32.Checksum(payload, crcTable) == sum:
+		return payload, nil
+	case last == remaining:
+		return nil, errTorn
+	default:
+		return nil, core.Wrap(ErrCorrupt, "checksum mismatch")
+	}
+}
+
+func writeBytes(buf *bytes.Buffer, b []byte) {
+	buf.Write(binary.AppendUvarint(nil, uint64(len(b))))
+	buf.Write(b)
+}
+
+func readBytes(br *bytes.Reader) ([]byte, error) {
+	n, err := binary.ReadUvarint(br)
+	if err != nil || n > uint64(br.Len()) {
+		return nil, ErrCorrupt
+	}
+
+	b := make([]byte, n)
+	_, _ = br.Read(b)
+	return b, nil
+}
@@ -0,0 +1,136 @@
+package behold
+
+import (
+	"slices"
+
+	"darvaza.org/core"
+)
+
+// FindOptions controls the order and the window of the entries
+// visited by Find.
+type FindOptions[V any] struct {
+	// Sort orders the entries by value. Use Reverse for descending
+	// order. Entries comparing equal keep the store's order.
+	// If nil, entries are visited in the store's order.
+	Sort CompFunc[V]
+
+	// Index optionally names an ordered index of the store whose order,
+	// ascending or descending as it was created, is consistent with Sort.
+	// Stores supporting it stream the entries following the index instead
+	// of collecting and sorting them all, only sorting those with the same
+	// indexed value. It's up to the caller to keep Sort consistent with
+	// the index, as it's trusted without checking. It's ignored if Sort
+	// is nil.
+	Index string
+
+	// Skip is the number of entries to skip before the first visited.
+	Skip int
+
+	// Limit is the maximum number of entries to visit, or zero
+	// for no limit.
+	Limit int
+}
+
+// Validate checks the options are valid.
+func (opts FindOptions[V]) Validate() error {
+	switch {
+	case opts.Skip < 0:
+		return core.Wrap(ErrInvalid, "negative skip")
+	case opts.Limit < 0:
+		return core.Wrap(ErrInvalid, "negative limit")
+	default:
+		return nil
+	}
+}
+
+// Window returns the part of a sorted slice selected by Skip and Limit.
+func (opts FindOptions[V]) Window(n int) (start, end int) {
+	start = min(opts.Skip, n)
+	end = n
+	if opts.Limit > 0 && start+opts.Limit < end {
+		end = start + opts.Limit
+	}
+	return start, end
+}
+
+// FindTx is a Tx able to sort, skip and limit the entries matching
+// a query by itself.
+type FindTx[K comparable, V any] interface {
+	Tx[K, V]
+
+	// Find calls fn for the entries whose value matches any of the given
+	// queries, or every entry if none is given, as selected by opts,
+	// until fn returns false.
+	Find(opts FindOptions[V], fn func(key K, value V) bool, ors ...Query[V]) error
+}
+
+// Find calls fn for the entries whose value matches any of the given
+// queries, or every entry if none is given, sorted, skipped and limited
+// as described by opts, until fn returns false.
+// If the transaction implements FindTx its Find method is used,
+// otherwise the matching entries are collected using ForEachMatch
+// and sorted in memory.
+func Find[K comparable, V any](tx Tx[K, V], opts FindOptions[V], fn func(key K, value V) bool,
+	ors ...Query[V]) error {
+	switch {
+	case tx == nil:
+		return ErrNilReceiver
+	case fn == nil:
+		return ErrInvalid
+	}
+
+	if ftx, ok := tx.(FindTx[K, V]); ok {
+		return ftx.Find(opts, fn, ors...)
+	}
+
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	if opts.Sort == nil {
+		return tx.ForEachMatch(windowFn(opts, fn), ors...)
+	}
+
+	var entries []Entry[K, V]
+	err := tx.ForEachMatch(func(k K, v V) bool {
+		entries = append(entries, Entry[K, V]{Key: k, Value: v})
+		return true
+	}, ors...)
+	if err != nil {
+		return err
+	}
+
+	slices.SortStableFunc(entries, func(a, b Entry[K, V]) int {
+		return opts.Sort(a.Value, b.Value)
+	})
+
+	start, end := opts.Window(len(entries))
+	for _, e := range entries[start:end] {
+		if !fn(e.Key, e.Value) {
+			break
+		}
+	}
+	return nil
+}
+
+// windowFn wraps fn to skip and limit the entries it's called for,
+// as described by opts.
+func windowFn[K comparable, V any](opts FindOptions[V], fn func(K, V) bool) func(K, V) bool {
+	skip, left := opts.Skip, opts.Limit
+	return func(k K, v V) bool {
+		if skip > 0 {
+			skip--
+			return true
+		}
+
+		if !fn(k, v) {
+			return false
+		}
+
+		if opts.Limit > 0 {
+			left--
+			return left > 0
+		}
+		return true
+	}
+}
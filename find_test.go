@@ -0,0 +1,79 @@
+package behold
+
+import (
+	"cmp"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceTx is a minimal read-only Tx over a slice of entries.
+type sliceTx struct {
+	Tx[string, int]
+
+	entries []Entry[string, int]
+	err     error
+}
+
+func (tx *sliceTx) Context() context.Context { return context.Background() }
+func (*sliceTx) Now() time.Time              { return time.Time{} }
+
+func (tx *sliceTx) ForEachMatch(fn func(string, int) bool, ors ...Query[int]) error {
+	q := MatchAll[int]()
+	if len(ors) > 0 {
+		q = MatchAny(ors...)
+	}
+
+	for _, e := range tx.entries {
+		if q.Match(e.Value) && !fn(e.Key, e.Value) {
+			break
+		}
+	}
+	return tx.err
+}
+func collectFind(t *testing.T, tx Tx[string, int], opts FindOptions[int], ors ...Query[int]) []string {
+	t.Helper()
+
+	var keys []string
+	err := Find(tx, opts, func(k string, _ int) bool {
+		keys = append(keys, k)
+		return true
+	}, ors...)
+	assert.NoError(t, err)
+	return keys
+}
+
+func TestFind(t *testing.T) {
+	tx := &sliceTx{
+		entries: []Entry[string, int]{
+			{"c", 3}, {"a", 1}, {"d", 4}, {"b", 2}, {"e", 2},
+		},
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts FindOptions[int]
+		ors  []Query[int]
+		want []string
+	}{
+		{"All", FindOptions[int]{}, nil, []string{"c", "a", "d", "b", "e"}},
+		{"Window", FindOptions[int]{Skip: 1, Limit: 2}, nil, []string{"a", "d"}},
+		{"Sort", FindOptions[int]{Sort: cmp.Compare[int]}, nil,
+			[]string{"a", "b", "e", "c", "d"}},
+		{"Reverse", FindOptions[int]{Sort: Reverse(cmp.Compare[int]), Limit: 3}, nil,
+			[]string{"d", "c", "b"}},
+		{"Match", FindOptions[int]{Sort: cmp.Compare[int], Skip: 1}, []Query[int]{GtQuery(1)},
+			[]string{"e", "c", "d"}},
+		{"Beyond", FindOptions[int]{Sort: cmp.Compare[int], Skip: 10}, nil, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, collectFind(t, tx, tc.opts, tc.ors...))
+		})
+	}
+
+	err := Find[string, int](tx, FindOptions[int]{Limit: -1}, func(string, int) bool { return true })
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.ErrorIs(t, Find[string, int](nil, FindOptions[int]{}, nil), ErrNilReceiver)
+}
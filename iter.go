@@ -0,0 +1,56 @@
+//go:build go1.23
+
+package behold
+
+import "iter"
+
+// Iterator provides range-over-func access to the entries of a
+// transaction whose value matches any of the queries, or every entry
+// if no queries are provided.
+// Errors stopping an iteration are reported by Err once the loop ends.
+type Iterator[K comparable, V any] struct {
+	tx  Tx[K, V]
+	ors []Query[V]
+	err error
+}
+
+// Iterate creates an Iterator over the entries of the transaction
+// matching any of the given queries.
+func Iterate[K comparable, V any](tx Tx[K, V], ors ...Query[V]) *Iterator[K, V] {
+	return &Iterator[K, V]{tx: tx, ors: ors}
+}
+
+// All returns an iterator over the matching key-value pairs.
+func (it *Iterator[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		it.run(yield)
+	}
+}
+
+// Keys returns an iterator over the keys of the matching entries.
+func (it *Iterator[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		it.run(func(k K, _ V) bool { return yield(k) })
+	}
+}
+
+// Values returns an iterator over the values of the matching entries.
+func (it *Iterator[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		it.run(func(_ K, v V) bool { return yield(v) })
+	}
+}
+
+// Err returns the error that stopped the last iteration, if any.
+func (it *Iterator[K, V]) Err() error {
+	return it.err
+}
+
+func (it *Iterator[K, V]) run(yield func(K, V) bool) {
+	if it.tx == nil {
+		it.err = ErrInvalid
+		return
+	}
+
+	it.err = it.tx.ForEachMatch(yield, it.ors...)
+}
@@ -0,0 +1,51 @@
+//go:build go1.23
+
+package behold
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterator(t *testing.T) {
+	tx := &sliceTx{
+		entries: []Entry[string, int]{
+			{"one", 1}, {"two", 2}, {"three", 3},
+		},
+	}
+
+	it := Iterate[string, int](tx)
+	assert.Equal(t, map[string]int{"one": 1, "two": 2, "three": 3}, maps.Collect(it.All()))
+	assert.NoError(t, it.Err())
+
+	it = Iterate[string, int](tx, GtQuery(1))
+	assert.Equal(t, []string{"two", "three"}, slices.Collect(it.Keys()))
+	assert.Equal(t, []int{2, 3}, slices.Collect(it.Values()))
+
+	// early break
+	var keys []string
+	for k := range Iterate[string, int](tx).Keys() {
+		keys = append(keys, k)
+		if len(keys) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"one", "two"}, keys)
+
+	// errors
+	tx.err = ErrClosed
+	it = Iterate[string, int](tx)
+	for range it.All() {
+		continue
+	}
+	assert.ErrorIs(t, it.Err(), ErrClosed)
+
+	it = Iterate[string, int](nil)
+	for range it.All() {
+		continue
+	}
+	assert.ErrorIs(t, it.Err(), ErrInvalid)
+}
@@ -0,0 +1,24 @@
+package behold
+
+import "time"
+
+// TxLeak describes a transaction started by BeginStore.Begin that
+// wasn't ended in time, as reported by stores detecting leaks.
+type TxLeak struct {
+	// Version is the data version accessed by the transaction.
+	Version uint64
+
+	// Writable tells if it's a read-write transaction.
+	Writable bool
+
+	// Started is when the transaction began.
+	Started time.Time
+
+	// Collected tells if the transaction was garbage-collected without
+	// being ended, instead of staying open past the threshold. Collected
+	// transactions are closed, discarding their changes.
+	Collected bool
+
+	// Stack is the stack trace of the goroutine that began it.
+	Stack []byte
+}
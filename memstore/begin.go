@@ -0,0 +1,107 @@
+package memstore
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.BeginStore[string, any] = (*Store[string, any])(nil)
+
+// Begin starts a transaction, read-write if writable is set, holding
+// the given locks until it's committed or closed. Unlike those of View
+// and Update, the transaction can be used after Begin returns, but
+// still not concurrently.
+//
+// Transactions not ended keep their version pinned, and their locks
+// held, until garbage-collected, and are reported to Config.OnLeak
+// if set.
+func (s *Store[K, V]) Begin(ctx context.Context, writable bool, locks ...behold.Mutex) (behold.Tx[K, V], error) {
+	switch {
+	case s == nil:
+		return nil, behold.ErrNilReceiver
+	case ctx == nil:
+		return nil, behold.ErrInvalid
+	case ctx.Err() != nil:
+		return nil, ctx.Err()
+	}
+
+	unlock, err := lockAll(ctx, locks)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.begin(ctx, writable)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+
+	tx.unlock = unlock
+	s.watchLeak(tx)
+	return tx, nil
+}
+
+// leakWatch reports a transaction started by Begin if it stays open
+// past the threshold, when there is a report function. It doesn't
+// reference the transaction, so it doesn't prevent it from being
+// garbage-collected.
+type leakWatch struct {
+	info   behold.TxLeak
+	report func(behold.TxLeak)
+	timer  *time.Timer
+	done   atomic.Bool
+}
+
+// watchLeak sets up the release of a leaked transaction once it's
+// garbage-collected, and its reporting if Config.OnLeak is set.
+func (s *Store[K, V]) watchLeak(tx *Tx[K, V]) {
+	w := &leakWatch{report: s.onLeak}
+	if w.report != nil {
+		w.info = behold.TxLeak{
+			Version:  tx.version,
+			Writable: tx.writable,
+			Started:  time.Now(),
+			Stack:    debug.Stack(),
+		}
+	}
+
+	if w.report != nil && s.leakTTL > 0 {
+		w.timer = time.AfterFunc(s.leakTTL, func() {
+			if !w.done.Load() {
+				w.report(w.info)
+			}
+		})
+	}
+
+	tx.watch = w
+	runtime.SetFinalizer(tx, (*Tx[K, V]).collected)
+}
+
+// stop prevents the transaction from being reported.
+func (w *leakWatch) stop() {
+	w.done.Store(true)
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// collected releases, and reports, a transaction garbage-collected
+// without being ended.
+func (tx *Tx[K, V]) collected() {
+	if tx.done {
+		return
+	}
+
+	tx.release()
+	if tx.watch.report != nil {
+		info := tx.watch.info
+		info.Collected = true
+		tx.watch.report(info)
+	}
+}
@@ -0,0 +1,142 @@
+package memstore
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func TestBegin(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	tx, err := s.Begin(ctx, true, &mu)
+	require.NoError(t, err)
+	assert.False(t, mu.TryLock())
+
+	require.NoError(t, tx.Set(keyOne, 10))
+	require.NoError(t, tx.Commit())
+	assert.True(t, mu.TryLock())
+	mu.Unlock()
+
+	assert.ErrorIs(t, tx.Set(keyOne, 20), behold.ErrClosed)
+	assert.Equal(t, uint64(2), s.Version())
+
+	// Close discards the changes
+	tx, err = s.Begin(ctx, true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Set(keyOne, 30))
+	require.NoError(t, tx.Close())
+	assert.Equal(t, uint64(2), s.Version())
+
+	tx, err = s.Begin(ctx, false)
+	require.NoError(t, err)
+	v, err := tx.Get(keyOne)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, v)
+	assert.ErrorIs(t, tx.Set(keyOne, 40), behold.ErrReadOnlyTx)
+	require.NoError(t, tx.Close())
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = s.Begin(cancelled, false)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	require.NoError(t, s.Close())
+	_, err = s.Begin(ctx, false, &mu)
+	assert.ErrorIs(t, err, behold.ErrClosed)
+	assert.True(t, mu.TryLock())
+}
+
+func TestBeginLeakThreshold(t *testing.T) {
+	leaks := make(chan behold.TxLeak, 1)
+	cfg := &Config[string, int]{
+		LeakThreshold: 10 * time.Millisecond,
+		OnLeak:        func(l behold.TxLeak) { leaks <- l },
+	}
+	s := cfg.New()
+	defer s.Close()
+
+	tx, err := s.Begin(context.Background(), true)
+	require.NoError(t, err)
+
+	select {
+	case l := <-leaks:
+		assert.True(t, l.Writable)
+		assert.False(t, l.Collected)
+		assert.Contains(t, string(l.Stack), "TestBeginLeakThreshold")
+	case <-time.After(5 * time.Second):
+		t.Fatal("leak not reported")
+	}
+	require.NoError(t, tx.Close())
+
+	// ended in time
+	tx, err = s.Begin(context.Background(), false)
+	require.NoError(t, err)
+	require.NoError(t, tx.Close())
+	time.Sleep(30 * time.Millisecond)
+	assert.Empty(t, leaks)
+}
+
+func TestBeginLeakCollected(t *testing.T) {
+	leaks := make(chan behold.TxLeak, 1)
+	cfg := &Config[string, int]{
+		OnLeak: func(l behold.TxLeak) { leaks <- l },
+	}
+	s := cfg.New()
+	defer s.Close()
+
+	var mu sync.Mutex
+	func() {
+		tx, err := s.Begin(context.Background(), true, &mu)
+		require.NoError(t, err)
+		require.NoError(t, tx.Set(keyOne, 1))
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		runtime.GC()
+		select {
+		case l := <-leaks:
+			assert.True(t, l.Collected)
+			assert.True(t, mu.TryLock(), "locks released")
+			assert.Equal(t, uint64(0), s.Version())
+			assert.Empty(t, s.pins)
+			return
+		case <-deadline:
+			t.Fatal("leak not reported")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestBeginCollected(t *testing.T) {
+	s := New[string, int]()
+	defer s.Close()
+
+	var mu sync.Mutex
+	func() {
+		tx, err := s.Begin(context.Background(), true, &mu)
+		require.NoError(t, err)
+		require.NoError(t, tx.Set(keyOne, 1))
+	}()
+
+	// released without OnLeak
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		if !mu.TryLock() {
+			return false
+		}
+		mu.Unlock()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, uint64(0), s.Version())
+}
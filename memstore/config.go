@@ -0,0 +1,80 @@
+package memstore
+
+import (
+	"cmp"
+	"time"
+
+	"github.com/amery/behold"
+)
+
+// Config describes how a Store is constructed.
+// The zero value is ready to use.
+type Config[K comparable, V any] struct {
+	// Now returns the time reference of the store and its transactions.
+	// If nil, time.Now is used.
+	Now func() time.Time
+
+	// Append combines the current value of a key with a new one
+	// when Tx.Append is called on an existing key.
+	// If nil, appending to an existing key fails with ErrInvalid.
+	Append func(key K, current, value V) (V, error)
+
+	// KeyOrder sorts the keys of the store, enabling range scans.
+	// If nil, entries are kept in the order they were first stored.
+	KeyOrder behold.CompFunc[K]
+
+	// Retain is the number of past versions kept available to
+	// ViewAt besides those still used by open transactions.
+	Retain uint64
+
+	// OnLeak, if set, is called with the transactions started by Begin
+	// that stay open longer than LeakThreshold, or are garbage-collected
+	// without being ended. The stack of every Begin is captured for it.
+	OnLeak func(behold.TxLeak)
+
+	// LeakThreshold is how long a transaction started by Begin can stay
+	// open before being reported to OnLeak. If zero, only transactions
+	// garbage-collected are reported.
+	LeakThreshold time.Duration
+}
+
+// New creates a new empty Store using the Config.
+// A nil Config is treated as the zero value.
+func (cfg *Config[K, V]) New() *Store[K, V] {
+	if cfg == nil {
+		cfg = new(Config[K, V])
+	}
+
+	s := &Store[K, V]{
+		now:      cfg.Now,
+		appendFn: cfg.Append,
+		keyOrder: cfg.KeyOrder,
+		retain:   cfg.Retain,
+		onLeak:   cfg.OnLeak,
+		leakTTL:  cfg.LeakThreshold,
+		entries:  make(map[K]*entry[K, V]),
+		garbage:  make(map[*entry[K, V]]struct{}),
+		pins:     make(map[uint64]int),
+	}
+
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	if s.keyOrder != nil {
+		s.order = newSkiplist(func(a, b *entry[K, V]) int {
+			return s.keyOrder(a.key, b.key)
+		})
+	} else {
+		s.order = newSkiplist(func(a, b *entry[K, V]) int {
+			return cmp.Compare(a.seq, b.seq)
+		})
+	}
+
+	return s
+}
+
+// New creates a new empty Store with the default configuration.
+func New[K comparable, V any]() *Store[K, V] {
+	return new(Config[K, V]).New()
+}
@@ -0,0 +1,121 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func TestContextRollback(t *testing.T) {
+	s := newTestStore(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set(keyOne, 10))
+		cancel()
+
+		assert.ErrorIs(t, tx.Set(keyTwo, 20), context.Canceled)
+		_, err := tx.Get(keyOne)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		// ignoring the error doesn't commit
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, uint64(1), s.Version())
+
+	// cancelled without touching the transaction again
+	ctx, cancel = context.WithCancel(context.Background())
+	err = s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set(keyOne, 10))
+		cancel()
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, uint64(1), s.Version())
+
+	// read-only transactions report it too
+	ctx, cancel = context.WithCancel(context.Background())
+	err = s.View(ctx, func(tx behold.Tx[string, int]) error {
+		cancel()
+		_, err := tx.Get(keyOne)
+		return err
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestContextScan(t *testing.T) {
+	s := New[string, int]()
+	defer s.Close()
+
+	require.NoError(t, s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		for i := 0; i < 4*ctxCheckInterval; i++ {
+			if err := tx.Set(fmt.Sprint(i), i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var visited int
+	err := s.View(ctx, func(tx behold.Tx[string, int]) error {
+		return tx.ForEach(func(string, int) bool {
+			visited++
+			cancel()
+			return true
+		})
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, ctxCheckInterval, visited)
+
+	// counting too
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+
+	visited = 0
+	err = s.View(ctx, func(tx behold.Tx[string, int]) error {
+		_, err := behold.Count(tx, behold.QueryFunc[int](func(int) bool {
+			visited++
+			cancel()
+			return true
+		}))
+		return err
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, ctxCheckInterval, visited)
+}
+
+func TestContextLocks(t *testing.T) {
+	s := newTestStore(t)
+
+	var mu sync.Mutex
+	mu.Lock()
+	defer mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	fn := func(behold.Tx[string, int]) error { return nil }
+	assert.ErrorIs(t, s.View(ctx, fn, &mu), context.DeadlineExceeded)
+	assert.ErrorIs(t, s.Update(ctx, fn, &mu), context.DeadlineExceeded)
+
+	_, err := s.Begin(ctx, true, &mu)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// locks acquired before giving up are released
+	var other sync.Mutex
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Update(ctx, fn, &other, &mu), context.DeadlineExceeded)
+	assert.True(t, other.TryLock())
+}
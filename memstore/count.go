@@ -0,0 +1,32 @@
+package memstore
+
+import (
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.CounterTx[string, any] = (*Tx[string, any])(nil)
+
+// Count returns the number of entries whose value matches any of the
+// given queries, or the number of entries if none is given. Queries
+// served by the store's indexes only check the candidates the indexes
+// return. Entries are copied in chunks, so the store's lock isn't held
+// while matching them.
+func (tx *Tx[K, V]) Count(ors ...behold.Query[V]) (int, error) {
+	if err := tx.check(false); err != nil {
+		return 0, err
+	}
+
+	var n int
+	match := matchAny(ors)
+	err := tx.scan(bounds[K]{}, false, ors, func(_ K, value V) bool {
+		if match(value) {
+			n++
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
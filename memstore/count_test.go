@@ -0,0 +1,80 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func TestCount(t *testing.T) {
+	s := newIndexedStore(t)
+	ctx := context.Background()
+
+	// a=10 b=20 c=30 d=40
+	for _, tc := range []struct {
+		name string
+		ors  []behold.Query[int]
+		want int
+	}{
+		{"All", nil, 4},
+		{"Index", []behold.Query[int]{byTens.Query(behold.GtQuery(1))}, 3},
+		{"Scan", []behold.Query[int]{behold.LtQuery(25)}, 2},
+		{"None", []behold.Query[int]{byTens.Query(behold.EqQuery(9))}, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, s.View(ctx, func(tx behold.Tx[string, int]) error {
+				n, err := behold.Count(tx, tc.ors...)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, n)
+				return nil
+			}))
+		})
+	}
+
+	// pending changes are counted
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("e", 50))
+		require.NoError(t, tx.Delete("b"))
+		require.NoError(t, tx.Set("a", 15))
+
+		for q, want := range map[behold.Query[int]]int{
+			byTens.Query(behold.GtQuery(1)): 3,
+			byTens.Query(behold.EqQuery(1)): 1,
+			behold.GtQuery(12):              4,
+		} {
+			n, err := behold.Count(tx, q)
+			assert.NoError(t, err)
+			assert.Equal(t, want, n, "%s", behold.DescribeQuery(q))
+		}
+
+		n, err := behold.Count(tx)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, n)
+		return tx.Close()
+	}))
+}
+
+func TestCountUnlocked(t *testing.T) {
+	s := newIndexedStore(t)
+	ctx := context.Background()
+
+	// writers aren't blocked while matching
+	q := behold.QueryFunc[int](func(v int) bool {
+		err := s.Update(ctx, func(tx behold.Tx[string, int]) error {
+			return tx.Set("e", 50)
+		})
+		assert.NoError(t, err)
+		return v > 15
+	})
+
+	require.NoError(t, s.View(ctx, func(tx behold.Tx[string, int]) error {
+		n, err := behold.Count(tx, q)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, n)
+		return nil
+	}))
+}
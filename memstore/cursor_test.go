@@ -0,0 +1,111 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func page(t *testing.T, s behold.Store[string, int], after *behold.Cursor[string], limit int,
+	ors ...behold.Query[int]) ([]string, *behold.Cursor[string], error) {
+	t.Helper()
+
+	var keys []string
+	opts := behold.PageOptions[string]{After: after, Limit: limit}
+	next, err := behold.Page(context.Background(), s, opts, func(k string, _ int) bool {
+		keys = append(keys, k)
+		return true
+	}, ors...)
+	return keys, next, err
+}
+
+func TestViewAt(t *testing.T) {
+	cfg := &Config[string, int]{Retain: 1}
+	s := cfg.New()
+	defer s.Close()
+
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+			return tx.Set(keyOne, i)
+		}))
+	}
+
+	err := s.ViewAt(ctx, 2, func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, uint64(2), tx.Version())
+		v, err := tx.Get(keyOne)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, v)
+		return nil
+	})
+	require.NoError(t, err)
+
+	noop := func(behold.Tx[string, int]) error { return nil }
+	assert.ErrorIs(t, s.ViewAt(ctx, 1, noop), behold.ErrSnapshotExpired)
+	assert.ErrorIs(t, s.ViewAt(ctx, 4, noop), behold.ErrInvalid)
+}
+
+func TestPage(t *testing.T) {
+	cfg := &Config[string, int]{KeyOrder: cmp.Compare[string], Retain: 1}
+	s := cfg.New()
+	defer s.Close()
+
+	ctx := context.Background()
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		for i, k := range []string{"a", "b", "c", "d", "e"} {
+			if err := tx.Set(k, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	keys, next, err := page(t, s, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	require.NotNil(t, next)
+
+	// pages are read from the same snapshot
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Delete("c"))
+		return tx.Set("bb", 10)
+	}))
+
+	keys, next, err = page(t, s, next, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "d"}, keys)
+
+	keys, last, err := page(t, s, next, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e"}, keys)
+	assert.Nil(t, last)
+
+	// and fail once it's gone
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		return tx.Set("f", 5)
+	}))
+	_, _, err = page(t, s, next, 2)
+	assert.ErrorIs(t, err, behold.ErrSnapshotExpired)
+}
+
+func TestPageInsertionOrder(t *testing.T) {
+	s := newTestStore(t)
+
+	var all []string
+	var after *behold.Cursor[string]
+	for {
+		keys, next, err := page(t, s, after, 1, behold.GtQuery(1))
+		require.NoError(t, err)
+		all = append(all, keys...)
+		if next == nil {
+			break
+		}
+		after = next
+	}
+	assert.Equal(t, []string{keyTwo, keyThree}, all)
+}
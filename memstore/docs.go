@@ -0,0 +1,3 @@
+// Package memstore provides a reference in-memory implementation of
+// the behold.Store and behold.Tx interfaces.
+package memstore
@@ -0,0 +1,163 @@
+package memstore
+
+// record is the value of a key as of a given version.
+type record[V any] struct {
+	version uint64
+	value   V
+	deleted bool
+}
+
+// entry holds the retained history of a key, oldest record first.
+// seq is the position of the entry in the insertion order.
+type entry[K comparable, V any] struct {
+	key     K
+	seq     uint64
+	history []record[V]
+}
+
+// at returns the value of the key as seen at the given version.
+func (e *entry[K, V]) at(version uint64) (V, bool) {
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if r := &e.history[i]; r.version <= version {
+			return r.value, !r.deleted
+		}
+	}
+
+	var zero V
+	return zero, false
+}
+
+// versionAt returns the version of the record seen at the given version,
+// or 0 if the key doesn't exist then.
+func (e *entry[K, V]) versionAt(version uint64) uint64 {
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if r := &e.history[i]; r.version <= version {
+			if r.deleted {
+				return 0
+			}
+			return r.version
+		}
+	}
+	return 0
+}
+
+// each calls fn for every live record of the entry.
+func (e *entry[K, V]) each(fn func(K, V)) {
+	for _, r := range e.history {
+		if !r.deleted {
+			fn(e.key, r.value)
+		}
+	}
+}
+
+// changedSince tells if the key has records newer than the given version.
+func (e *entry[K, V]) changedSince(version uint64) bool {
+	n := len(e.history)
+	return n > 0 && e.history[n-1].version > version
+}
+
+// compact drops records no transaction at or after the horizon
+// version can see, passing the live ones to drop. It returns true if
+// nothing else can be reclaimed until the entry is modified again.
+func (e *entry[K, V]) compact(horizon uint64, drop func(K, V)) bool {
+	i := len(e.history) - 1
+	for i > 0 && e.history[i].version > horizon {
+		i--
+	}
+
+	if i > 0 {
+		for _, r := range e.history[:i] {
+			if !r.deleted {
+				drop(e.key, r.value)
+			}
+		}
+		e.history = append(e.history[:0], e.history[i:]...)
+	}
+
+	if first := &e.history[0]; first.deleted && first.version <= horizon {
+		e.history = e.history[1:]
+	}
+
+	return e.clean()
+}
+
+// clean tells if the entry holds a single live record.
+func (e *entry[K, V]) clean() bool {
+	return len(e.history) == 1 && !e.history[0].deleted
+}
+
+// pin registers an open transaction accessing the given version.
+// s.mu must be held for writing.
+func (s *Store[K, V]) pin(version uint64) {
+	if len(s.pins) == 0 || version < s.minPin {
+		s.minPin = version
+	}
+	s.pins[version]++
+}
+
+// unpin releases a version pinned by a transaction and, if that
+// advances the horizon, reclaims the records nobody can see anymore.
+// s.mu must be held for writing.
+func (s *Store[K, V]) unpin(version uint64) {
+	if n := s.pins[version]; n > 1 {
+		s.pins[version] = n - 1
+		return
+	}
+
+	delete(s.pins, version)
+	if version == s.minPin {
+		s.minPin = minKey(s.pins)
+	}
+
+	// records are only dropped once the horizon moves
+	if s.horizon() > s.floor {
+		s.reclaim()
+	}
+}
+
+// minKey returns the lowest pinned version, or 0 if none.
+func minKey(pins map[uint64]int) uint64 {
+	var lowest uint64
+	first := true
+	for v := range pins {
+		if first || v < lowest {
+			lowest, first = v, false
+		}
+	}
+	return lowest
+}
+
+// horizon returns the oldest version that can still be accessed,
+// considering the retained versions.
+func (s *Store[K, V]) horizon() uint64 {
+	horizon := uint64(0)
+	if s.version > s.retain {
+		horizon = s.version - s.retain
+	}
+
+	if len(s.pins) > 0 && s.minPin < horizon {
+		horizon = s.minPin
+	}
+	return horizon
+}
+
+// reclaim compacts the history of modified entries up to the horizon,
+// removing those left empty. s.mu must be held for writing.
+func (s *Store[K, V]) reclaim() {
+	if s.closed {
+		return
+	}
+
+	horizon := s.horizon()
+	s.floor = horizon
+
+	for e := range s.garbage {
+		if e.compact(horizon, s.unindex) {
+			delete(s.garbage, e)
+		} else if len(e.history) == 0 {
+			delete(s.garbage, e)
+			delete(s.entries, e.key)
+			s.order.Remove(e)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package memstore
+
+import (
+	"slices"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.FindTx[string, any] = (*Tx[string, any])(nil)
+
+// Find calls fn for the entries whose value matches any of the given
+// queries, or every entry if none is given, sorted, skipped and limited
+// as described by opts, until fn returns false.
+//
+// If opts names an index, and the transaction has no pending changes,
+// entries are collected following the index, only sorting those with
+// the same indexed value, and stopping once the limit is reached.
+// opts.Sort is trusted to follow the order the index was created with,
+// and entries are visited in the order of the index if it doesn't.
+func (tx *Tx[K, V]) Find(opts behold.FindOptions[V], fn func(key K, value V) bool, ors ...behold.Query[V]) error {
+	switch err := tx.check(false); {
+	case err != nil:
+		return err
+	case fn == nil:
+		return behold.ErrInvalid
+	}
+
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	pairs, err := tx.find(opts, ors)
+	if err != nil {
+		return err
+	}
+
+	start, end := opts.Window(len(pairs))
+	for i, p := range pairs[start:end] {
+		if err := tx.checkEvery(i); err != nil {
+			return err
+		}
+
+		if !fn(p.key, p.value) {
+			break
+		}
+	}
+	return nil
+}
+
+// find returns the entries matching the queries in the order
+// described by opts, so they can be visited without holding
+// the store's lock. The lock isn't held either while matching
+// and sorting them.
+func (tx *Tx[K, V]) find(opts behold.FindOptions[V], ors []behold.Query[V]) ([]pair[K, V], error) {
+	match := matchAny(ors)
+	if opts.Sort != nil && opts.Index != "" {
+		idx, err := tx.findIndex(opts.Index)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(tx.changes) == 0 {
+			f := &indexFind[K, V]{tx: tx, idx: idx, opts: opts, match: match}
+			return f.run()
+		}
+	}
+
+	return tx.findAll(opts, ors, match)
+}
+
+// findAll returns all the entries matching, sorted as described by opts.
+func (tx *Tx[K, V]) findAll(opts behold.FindOptions[V], ors []behold.Query[V],
+	match func(V) bool) ([]pair[K, V], error) {
+	var pairs []pair[K, V]
+	err := tx.scan(bounds[K]{}, false, ors, func(key K, value V) bool {
+		if match(value) {
+			pairs = append(pairs, pair[K, V]{key, value})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Sort != nil {
+		slices.SortStableFunc(pairs, func(a, b pair[K, V]) int {
+			return opts.Sort(a.value, b.value)
+		})
+	}
+	return pairs, nil
+}
+
+// findIndex returns the named index.
+func (tx *Tx[K, V]) findIndex(name string) (index[K, V], error) {
+	s := tx.s
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, behold.ErrClosed
+	}
+
+	idx, ok := s.getIndex(name)
+	if !ok {
+		return nil, core.Wrapf(behold.ErrInvalid, "index %q not found", name)
+	}
+	return idx, nil
+}
+
+// indexFind collects the entries matching in the order of an index,
+// sorting those with the same indexed value, until enough have been
+// collected for the window described by opts. The index is walked in
+// chunks, so the store's lock isn't held while matching and sorting.
+// The transaction must have no pending changes.
+type indexFind[K comparable, V any] struct {
+	tx    *Tx[K, V]
+	idx   index[K, V]
+	opts  behold.FindOptions[V]
+	match func(V) bool
+
+	// after is the position of the last indexed value
+	// of the previous chunk.
+	after any
+	count int
+	out   []pair[K, V]
+}
+
+func (f *indexFind[K, V]) run() ([]pair[K, V], error) {
+	for {
+		buckets, more, err := f.next()
+		if err != nil {
+			return nil, err
+		}
+
+		full, err := f.addAll(buckets)
+		switch {
+		case err != nil:
+			return nil, err
+		case full || !more:
+			return f.out, nil
+		}
+	}
+}
+
+// addAll adds the entries of the given indexed values, telling
+// if enough have been collected.
+func (f *indexFind[K, V]) addAll(buckets [][]pair[K, V]) (bool, error) {
+	need := f.opts.Skip + f.opts.Limit
+	for _, b := range buckets {
+		if err := f.add(b); err != nil {
+			return false, err
+		}
+
+		if f.opts.Limit > 0 && len(f.out) >= need {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// next copies the entries of the indexed values following the
+// previous chunk, up to scanChunk unless a single value has more,
+// and tells if more could follow them.
+func (f *indexFind[K, V]) next() ([][]pair[K, V], bool, error) {
+	s := f.tx.s
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, false, behold.ErrClosed
+	}
+
+	var buckets [][]pair[K, V]
+	var size int
+	more := false
+	f.idx.walk(f.idx.descending(), f.after, func(keys []K, has func(V) bool, at any) bool {
+		if size >= scanChunk {
+			more = true
+			return false
+		}
+
+		b := f.tx.appendVisible(nil, keys, has)
+		buckets = append(buckets, b)
+		size += len(b)
+		f.after = at
+		return true
+	})
+	return buckets, more, nil
+}
+
+// add appends the entries of an indexed value that match, sorted.
+func (f *indexFind[K, V]) add(b []pair[K, V]) error {
+	n := len(f.out)
+	for _, p := range b {
+		if err := f.tx.checkEvery(f.count); err != nil {
+			return err
+		}
+		f.count++
+
+		if f.match(p.value) {
+			f.out = append(f.out, p)
+		}
+	}
+
+	slices.SortStableFunc(f.out[n:], func(a, b pair[K, V]) int {
+		return f.opts.Sort(a.value, b.value)
+	})
+	return nil
+}
+
+// appendVisible adds the given keys, as seen by the transaction and
+// accepted by match, in the store's order. s.mu must be held and the
+// transaction must have no pending changes.
+func (tx *Tx[K, V]) appendVisible(out []pair[K, V], keys []K, match func(V) bool) []pair[K, V] {
+	entries := make([]*entry[K, V], 0, len(keys))
+	for _, key := range keys {
+		if e, ok := tx.s.entries[key]; ok {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, tx.s.order.cmp)
+
+	for _, e := range entries {
+		if v, ok := tx.at(e); ok && match(v) {
+			out = append(out, pair[K, V]{e.key, v})
+		}
+	}
+	return out
+}
+
+// matchAny returns a function telling if a value matches any of the
+// queries, or true for every value if none is given.
+func matchAny[V any](ors []behold.Query[V]) func(V) bool {
+	if len(ors) == 0 {
+		return func(V) bool { return true }
+	}
+
+	q := behold.MatchAny(ors...)
+	return q.Match
+}
@@ -0,0 +1,153 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func findKeys(t *testing.T, tx behold.Tx[string, int], opts behold.FindOptions[int],
+	ors ...behold.Query[int]) []string {
+	t.Helper()
+
+	var keys []string
+	require.NoError(t, behold.Find(tx, opts, func(k string, _ int) bool {
+		keys = append(keys, k)
+		return true
+	}, ors...))
+	return keys
+}
+
+func TestFind(t *testing.T) {
+	s := newIndexedStore(t)
+	require.NoError(t, AddReverseIndex(s, "rtens", byTens))
+	ctx := context.Background()
+
+	// a=10 b=20 c=30 d=40 e=25 f=5
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("e", 25))
+		return tx.Set("f", 5)
+	}))
+
+	asc := cmp.Compare[int]
+	desc := behold.Reverse(asc)
+
+	for _, tc := range []struct {
+		name string
+		opts behold.FindOptions[int]
+		ors  []behold.Query[int]
+		want []string
+	}{
+		{"Store", behold.FindOptions[int]{Limit: 2}, nil, []string{"a", "b"}},
+		{"Sort", behold.FindOptions[int]{Sort: asc}, nil,
+			[]string{"f", "a", "b", "e", "c", "d"}},
+		{"IndexAsc", behold.FindOptions[int]{Sort: asc, Index: "tens"}, nil,
+			[]string{"f", "a", "b", "e", "c", "d"}},
+		{"IndexMatch", behold.FindOptions[int]{Sort: desc, Index: "rtens", Limit: 2},
+			[]behold.Query[int]{behold.LtQuery(25)},
+			[]string{"b", "a"}},
+		{"ReverseIndex", behold.FindOptions[int]{Sort: desc, Index: "rtens", Skip: 1, Limit: 3}, nil,
+			[]string{"c", "e", "b"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, s.View(ctx, func(tx behold.Tx[string, int]) error {
+				assert.Equal(t, tc.want, findKeys(t, tx, tc.opts, tc.ors...))
+				return nil
+			}))
+		})
+	}
+
+	// pending changes are sorted in memory
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("g", 35))
+		require.NoError(t, tx.Delete("c"))
+		assert.Equal(t, []string{"d", "g", "e"},
+			findKeys(t, tx, behold.FindOptions[int]{Sort: desc, Index: "rtens", Limit: 3}))
+		return tx.Close()
+	}))
+
+	require.NoError(t, s.View(ctx, func(tx behold.Tx[string, int]) error {
+		err := tx.(*Tx[string, int]).Find(behold.FindOptions[int]{Sort: asc, Index: "none"},
+			func(string, int) bool { return true })
+		assert.ErrorIs(t, err, behold.ErrInvalid)
+		return nil
+	}))
+}
+
+func TestFindIndexTrusted(t *testing.T) {
+	s := newIndexedStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set("a", 10))
+		require.NoError(t, tx.Set("b", 29))
+		require.NoError(t, tx.Set("c", 31))
+		return tx.Set("d", 48)
+	}))
+
+	byUnits := func(a, b int) int { return cmp.Compare(units(a), units(b)) }
+	require.NoError(t, s.View(ctx, func(tx behold.Tx[string, int]) error {
+		opts := behold.FindOptions[int]{Sort: byUnits, Limit: 2}
+		assert.Equal(t, []string{"a", "c"}, findKeys(t, tx, opts))
+
+		// a sort not following the index isn't noticed,
+		// so entries come in the order of the index
+		opts.Index = "tens"
+		assert.Equal(t, []string{"a", "b"}, findKeys(t, tx, opts))
+		return nil
+	}))
+}
+
+func TestFindChunks(t *testing.T) {
+	const n = 3*scanChunk + 10
+
+	var keys []string
+	for i := 0; i < n; i++ {
+		keys = append(keys, fmt.Sprintf("k%04d", i))
+	}
+	s := newOrderedStore(t, keys...)
+	require.NoError(t, AddReverseIndex(s, "rtens", byTens))
+
+	desc := behold.Reverse(cmp.Compare[int])
+	slices.Reverse(keys)
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		opts := behold.FindOptions[int]{Sort: desc, Index: "rtens"}
+		assert.Equal(t, keys, findKeys(t, tx, opts))
+
+		opts.Skip, opts.Limit = scanChunk-5, 2*scanChunk
+		assert.Equal(t, keys[opts.Skip:opts.Skip+opts.Limit], findKeys(t, tx, opts))
+		return nil
+	}))
+}
+
+func TestFindUnlocked(t *testing.T) {
+	s := newIndexedStore(t)
+	ctx := context.Background()
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		return tx.Set("e", 15)
+	}))
+
+	for _, index := range []string{"", "tens"} {
+		// writers aren't blocked while sorting
+		sort := func(a, b int) int {
+			err := s.Update(ctx, func(tx behold.Tx[string, int]) error {
+				return tx.Set("a", 10)
+			})
+			assert.NoError(t, err)
+			return cmp.Compare(a, b)
+		}
+
+		require.NoError(t, s.View(ctx, func(tx behold.Tx[string, int]) error {
+			opts := behold.FindOptions[int]{Sort: sort, Index: index}
+			assert.Equal(t, []string{"a", "e", "b", "c", "d"}, findKeys(t, tx, opts))
+			return nil
+		}))
+	}
+}
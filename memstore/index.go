@@ -0,0 +1,330 @@
+package memstore
+
+import (
+	"cmp"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+)
+
+// index is a secondary index of a Store. Indexes hold every retained
+// record of the store, so the keys they return are candidates that
+// still need to be checked against the version seen by a transaction.
+type index[K comparable, V any] interface {
+	// Name returns the name the index was registered with.
+	Name() string
+
+	// add registers a record of a key.
+	add(key K, value V)
+
+	// remove unregisters a record of a key.
+	remove(key K, value V)
+
+	// serves tells if the index can resolve the query.
+	serves(q behold.Query[V]) bool
+
+	// lookup returns the keys that could match a query
+	// the index serves.
+	lookup(q behold.Query[V]) map[K]struct{}
+
+	// descending tells if the index is ordered by descending values.
+	descending() bool
+
+	// walk calls fn for every indexed value past the position after,
+	// or from the first if nil, in ascending order or descending if desc
+	// is set, until fn returns false. fn is given the keys having records
+	// of the value, a function telling if a value has it, and its position.
+	walk(desc bool, after any, fn func(keys []K, has func(V) bool, at any) bool)
+}
+
+// AddIndex registers a secondary index on a Store over the values
+// returned by the Accessor. Queries built by the same Accessor with a
+// comparison query like behold.EqQuery, behold.GtQuery or
+// behold.LtQuery, a range like behold.BetweenQuery, a set like
+// behold.InQuery, or a prefix of strings like behold.HasPrefixQuery,
+// are then served by the index instead of scanning the whole store.
+// Queries built by behold.ComposeQuery are never served by an index,
+// even using the same function.
+//
+// The index is ordered by ascending values, which Find follows when
+// sorting in the same order.
+func AddIndex[K comparable, V any, X core.Ordered](s *Store[K, V], name string, a *behold.Accessor[V, X]) error {
+	return registerIndex(s, name, a, ascending)
+}
+
+// AddReverseIndex registers a secondary index like AddIndex, but
+// ordered by descending values, which Find follows when sorting
+// in the same order.
+func AddReverseIndex[K comparable, V any, X core.Ordered](s *Store[K, V], name string,
+	a *behold.Accessor[V, X]) error {
+	return registerIndex(s, name, a, descending)
+}
+
+// indexOrder is the order of the values of an index.
+type indexOrder int
+
+const (
+	ascending indexOrder = iota
+	descending
+)
+
+func registerIndex[K comparable, V any, X core.Ordered](s *Store[K, V], name string, a *behold.Accessor[V, X],
+	order indexOrder) error {
+	switch {
+	case s == nil:
+		return behold.ErrNilReceiver
+	case name == "", a == nil:
+		return behold.ErrInvalid
+	}
+
+	return s.attachIndex(&fieldIndex[K, V, X]{
+		name:    name,
+		acc:     a,
+		order:   order,
+		values:  newSkiplist(cmpBucket[K, X]),
+		buckets: make(map[X]*bucket[K, X]),
+	})
+}
+
+// attachIndex registers an index, adding the retained records to it.
+func (s *Store[K, V]) attachIndex(idx index[K, V]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return behold.ErrClosed
+	}
+
+	if _, ok := s.getIndex(idx.Name()); ok {
+		return core.Wrapf(core.ErrExists, "index %q", idx.Name())
+	}
+
+	for _, e := range s.entries {
+		e.each(idx.add)
+	}
+
+	s.indexes = append(s.indexes, idx)
+	return nil
+}
+
+// index registers a new record on every index.
+// s.mu must be held for writing.
+func (s *Store[K, V]) index(key K, value V) {
+	for _, idx := range s.indexes {
+		idx.add(key, value)
+	}
+}
+
+// unindex unregisters a record from every index.
+// s.mu must be held for writing.
+func (s *Store[K, V]) unindex(key K, value V) {
+	for _, idx := range s.indexes {
+		idx.remove(key, value)
+	}
+}
+
+// getIndex returns the index registered with the given name.
+// s.mu must be held.
+func (s *Store[K, V]) getIndex(name string) (index[K, V], bool) {
+	for _, idx := range s.indexes {
+		if idx.Name() == name {
+			return idx, true
+		}
+	}
+	return nil, false
+}
+
+// fieldIndex indexes the keys of a store by the result of an Accessor
+// on their values.
+type fieldIndex[K comparable, V any, X core.Ordered] struct {
+	name    string
+	acc     *behold.Accessor[V, X]
+	order   indexOrder
+	values  *skiplist[*bucket[K, X]]
+	buckets map[X]*bucket[K, X]
+}
+
+// bucket holds the keys of the records with a given indexed value,
+// counting how many records of each key have it.
+type bucket[K comparable, X core.Ordered] struct {
+	x    X
+	keys map[K]int
+}
+
+func cmpBucket[K comparable, X core.Ordered](a, b *bucket[K, X]) int {
+	return cmp.Compare(a.x, b.x)
+}
+
+func (idx *fieldIndex[K, V, X]) Name() string { return idx.name }
+
+func (idx *fieldIndex[K, V, X]) descending() bool { return idx.order == descending }
+
+func (idx *fieldIndex[K, V, X]) add(key K, value V) {
+	x := idx.acc.Get(value)
+
+	b, ok := idx.buckets[x]
+	if !ok {
+		b = &bucket[K, X]{x: x, keys: make(map[K]int)}
+		idx.buckets[x] = b
+		idx.values.Insert(b)
+	}
+	b.keys[key]++
+}
+
+func (idx *fieldIndex[K, V, X]) remove(key K, value V) {
+	x := idx.acc.Get(value)
+
+	b, ok := idx.buckets[x]
+	if !ok {
+		return
+	}
+
+	if n := b.keys[key]; n > 1 {
+		b.keys[key] = n - 1
+		return
+	}
+
+	delete(b.keys, key)
+	if len(b.keys) == 0 {
+		delete(idx.buckets, x)
+		idx.values.Remove(b)
+	}
+}
+
+// selection returns the query applied to the indexed values
+// by the given one, if the index can serve it.
+func (idx *fieldIndex[K, V, X]) selection(q behold.Query[V]) (behold.Query[X], bool) {
+	base, ok := idx.acc.BaseOf(q)
+	if !ok {
+		return nil, false
+	}
+
+	switch base := base.(type) {
+	case behold.ComparisonQuery[X]:
+		switch base.Op() {
+		case behold.OpEq, behold.OpGt, behold.OpGtEq, behold.OpLt, behold.OpLtEq:
+			return base, true
+		}
+	case behold.RangeQuery[X]:
+		return base, base.Comparison() == nil
+	case behold.SetQuery[X]:
+		return base, base.Comparison() == nil && !base.Negated()
+	case behold.PrefixQuery[X]:
+		return base, true
+	}
+	return nil, false
+}
+
+func (idx *fieldIndex[K, V, X]) serves(q behold.Query[V]) bool {
+	_, ok := idx.selection(q)
+	return ok
+}
+
+func (idx *fieldIndex[K, V, X]) lookup(q behold.Query[V]) map[K]struct{} {
+	out := make(map[K]struct{})
+
+	base, _ := idx.selection(q)
+	switch base := base.(type) {
+	case behold.ComparisonQuery[X]:
+		idx.lookupComparison(out, base.Op(), base.Operand())
+	case behold.RangeQuery[X]:
+		lo, hi := base.Bounds()
+		idx.collectRange(out, &lo, true, &hi, true)
+	case behold.SetQuery[X]:
+		for _, x := range base.Values() {
+			idx.lookupComparison(out, behold.OpEq, x)
+		}
+	case behold.PrefixQuery[X]:
+		idx.collectPrefix(out, base)
+	}
+	return out
+}
+
+func (idx *fieldIndex[K, V, X]) lookupComparison(out map[K]struct{}, op behold.CompareOp, x X) {
+	switch op {
+	case behold.OpEq:
+		if b, ok := idx.buckets[x]; ok {
+			collectBucket(out, b)
+		}
+	case behold.OpGt, behold.OpGtEq:
+		idx.collectRange(out, &x, op == behold.OpGtEq, nil, false)
+	case behold.OpLt, behold.OpLtEq:
+		idx.collectRange(out, nil, false, &x, op == behold.OpLtEq)
+	}
+}
+
+func (idx *fieldIndex[K, V, X]) walk(desc bool, after any, fn func([]K, func(V) bool, any) bool) {
+	next := (*slnode[*bucket[K, X]]).Next
+	if desc {
+		next = (*slnode[*bucket[K, X]]).Prev
+	}
+
+	for n := idx.resume(desc, after); n != nil; n = next(n) {
+		x := n.value.x
+		has := func(v V) bool { return idx.acc.Get(v) == x }
+		if !fn(core.Keys(n.value.keys), has, x) {
+			return
+		}
+	}
+}
+
+// resume returns the first node of a walk past the given position,
+// an indexed value, or from the start if nil.
+func (idx *fieldIndex[K, V, X]) resume(desc bool, after any) *slnode[*bucket[K, X]] {
+	x, ok := after.(X)
+	switch {
+	case !ok && desc:
+		return idx.values.Last()
+	case !ok:
+		return idx.values.First()
+	case desc:
+		return skipBucket(idx.values.SeekLast(&bucket[K, X]{x: x}), x, (*slnode[*bucket[K, X]]).Prev)
+	default:
+		return skipBucket(idx.values.Seek(&bucket[K, X]{x: x}), x, (*slnode[*bucket[K, X]]).Next)
+	}
+}
+
+// skipBucket steps over the node of the given value, if present.
+func skipBucket[K comparable, X core.Ordered](n *slnode[*bucket[K, X]], x X,
+	next func(*slnode[*bucket[K, X]]) *slnode[*bucket[K, X]]) *slnode[*bucket[K, X]] {
+	if n != nil && n.value.x == x {
+		return next(n)
+	}
+	return n
+}
+
+// collectRange adds the keys of the values between lo and hi,
+// each inclusive or not, and open if nil.
+func (idx *fieldIndex[K, V, X]) collectRange(out map[K]struct{}, lo *X, loInc bool, hi *X, hiInc bool) {
+	n := idx.values.First()
+	if lo != nil {
+		n = idx.values.Seek(&bucket[K, X]{x: *lo})
+		if n != nil && !loInc && n.value.x == *lo {
+			n = n.Next()
+		}
+	}
+
+	for ; n != nil; n = n.Next() {
+		if hi != nil {
+			if c := cmp.Compare(n.value.x, *hi); c > 0 || (c == 0 && !hiInc) {
+				break
+			}
+		}
+		collectBucket(out, n.value)
+	}
+}
+
+// collectPrefix adds the keys of the values starting with the prefix,
+// which follow it contiguously in byte order.
+func (idx *fieldIndex[K, V, X]) collectPrefix(out map[K]struct{}, q behold.PrefixQuery[X]) {
+	for n := idx.values.Seek(&bucket[K, X]{x: q.Prefix()}); n != nil && q.Match(n.value.x); n = n.Next() {
+		collectBucket(out, n.value)
+	}
+}
+
+func collectBucket[K comparable, X core.Ordered](out map[K]struct{}, b *bucket[K, X]) {
+	for k := range b.keys {
+		out[k] = struct{}{}
+	}
+}
@@ -0,0 +1,196 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"testing"
+
+	"darvaza.org/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func tens(v int) int { return v / 10 }
+
+var byTens = behold.NewAccessor(tens)
+
+func newIndexedStore(t *testing.T) *Store[string, int] {
+	t.Helper()
+
+	s := newOrderedStore(t, "a", "b", "c", "d")
+	require.NoError(t, s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		for i, key := range []string{"a", "b", "c", "d"} {
+			if err := tx.Set(key, (i+1)*10); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, AddIndex(s, "tens", byTens))
+	return s
+}
+
+func matchKeys(t *testing.T, tx behold.Tx[string, int], ors ...behold.Query[int]) []string {
+	t.Helper()
+
+	var keys []string
+	require.NoError(t, tx.ForEachMatch(func(k string, _ int) bool {
+		keys = append(keys, k)
+		return true
+	}, ors...))
+	return keys
+}
+
+// candidates returns the sorted keys the indexes give for the queries,
+// or nil if they require a scan.
+func candidates(t *testing.T, s *Store[string, int], ors ...behold.Query[int]) []string {
+	t.Helper()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := s.plan(ors).root.keys()
+	if keys == nil {
+		return nil
+	}
+	return core.SortedKeys(keys)
+}
+
+func TestAddIndex(t *testing.T) {
+	s := newIndexedStore(t)
+
+	err := AddIndex(s, "tens", byTens)
+	assert.ErrorIs(t, err, core.ErrExists)
+	assert.ErrorIs(t, AddIndex(s, "", byTens), behold.ErrInvalid)
+	assert.ErrorIs(t, AddIndex[string](nil, "tens", byTens), behold.ErrNilReceiver)
+
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, AddIndex(s, "other", byTens), behold.ErrClosed)
+}
+
+func TestIndexLookup(t *testing.T) {
+	s := newIndexedStore(t)
+
+	for _, tc := range []struct {
+		name string
+		q    behold.Query[int]
+		keys []string
+	}{
+		{"Eq", byTens.Query(behold.EqQuery(2)), []string{"b"}},
+		{"Gt", byTens.Query(behold.GtQuery(2)), []string{"c", "d"}},
+		{"GtEq", byTens.Query(behold.GtEqQuery(2)), []string{"b", "c", "d"}},
+		{"Lt", byTens.Query(behold.LtQuery(2)), []string{"a"}},
+		{"LtEq", byTens.Query(behold.LtEqQuery(2)), []string{"a", "b"}},
+		{"Between", byTens.Query(behold.BetweenQuery(2, 3)), []string{"b", "c"}},
+		{"In", byTens.Query(behold.InQuery(1, 4, 7)), []string{"a", "d"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.keys, candidates(t, s, tc.q))
+
+			require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+				assert.Equal(t, tc.keys, matchKeys(t, tx, tc.q))
+				return nil
+			}))
+		})
+	}
+
+	for _, q := range []behold.Query[int]{
+		byTens.Query(behold.NotEqQuery(2)),
+		byTens.Query(behold.NotInQuery(2)),
+		byTens.Query(behold.BetweenQueryFn(2, 3, cmp.Compare[int])),
+		behold.ComposeQuery(tens, behold.EqQuery(2)),
+		behold.NewAccessor(tens).Query(behold.EqQuery(2)),
+		behold.EqQuery(20),
+	} {
+		assert.Nil(t, candidates(t, s, q))
+	}
+}
+
+func TestIndexClosures(t *testing.T) {
+	div := func(n int) *behold.Accessor[int, int] {
+		return behold.NewAccessor(func(v int) int { return v / n })
+	}
+
+	s := newIndexedStore(t)
+	byTenths := div(10)
+	byThirds := div(3)
+	require.NoError(t, AddIndex(s, "tenths", byTenths))
+
+	// same code, different accessors
+	q := byThirds.Query(behold.EqQuery(10))
+	assert.Nil(t, candidates(t, s, q))
+	assert.Equal(t, []string{"b", "c", "d"},
+		candidates(t, s, byTenths.Query(behold.GtQuery(1))))
+
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, []string{"c"}, matchKeys(t, tx, q))
+		return nil
+	}))
+}
+
+func spelled(v int) string {
+	return map[int]string{10: "ten", 20: "twenty", 30: "thirty", 40: "forty"}[v]
+}
+
+var bySpelled = behold.NewAccessor(spelled)
+
+func TestIndexPrefix(t *testing.T) {
+	s := newIndexedStore(t)
+	require.NoError(t, AddIndex(s, "spelled", bySpelled))
+
+	for prefix, keys := range map[string][]string{
+		"t":   {"a", "b", "c"},
+		"tw":  {"b"},
+		"f":   {"d"},
+		"x":   {},
+		"":    {"a", "b", "c", "d"},
+		"tho": {},
+	} {
+		q := bySpelled.Query(behold.HasPrefixQuery(prefix))
+		assert.Equal(t, keys, candidates(t, s, q), prefix)
+	}
+
+	q := bySpelled.Query(behold.HasSuffixQuery("ty"))
+	assert.Nil(t, candidates(t, s, q))
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, []string{"b", "c", "d"}, matchKeys(t, tx, q))
+		return nil
+	}))
+}
+
+func TestIndexVersions(t *testing.T) {
+	s := newIndexedStore(t)
+	ctx := context.Background()
+	q := byTens.Query(behold.EqQuery(5))
+
+	require.NoError(t, s.View(ctx, func(old behold.Tx[string, int]) error {
+		require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+			require.NoError(t, tx.Set("b", 50))
+			require.NoError(t, tx.Set("e", 55))
+			assert.Equal(t, []string{"b", "e"}, matchKeys(t, tx, q))
+			return nil
+		}))
+
+		// the old snapshot doesn't see the new values
+		assert.Empty(t, matchKeys(t, old, q))
+		assert.Equal(t, []string{"b"},
+			matchKeys(t, old, byTens.Query(behold.EqQuery(2))))
+		return nil
+	}))
+
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, []string{"b", "e"}, matchKeys(t, tx, q))
+
+		// pending changes are considered
+		require.NoError(t, tx.Set("a", 51))
+		require.NoError(t, tx.Delete("e"))
+		assert.Equal(t, []string{"a", "b"}, matchKeys(t, tx, q))
+		return nil
+	}))
+
+	// superseded records are dropped from the index once reclaimed
+	assert.Equal(t, []string{"c", "d"},
+		candidates(t, s, byTens.Query(behold.LtQuery(5))))
+}
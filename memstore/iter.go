@@ -0,0 +1,44 @@
+//go:build go1.23
+
+package memstore
+
+import (
+	"iter"
+
+	"github.com/amery/behold"
+)
+
+// All returns an iterator over the key-value pairs whose value matches
+// any of the given queries, or every entry if none is given.
+// Errors stopping the iteration are reported by Err.
+func (tx *Tx[K, V]) All(ors ...behold.Query[V]) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		tx.iterate(yield, ors)
+	}
+}
+
+// Keys returns an iterator over the keys of the entries whose value
+// matches any of the given queries, or every entry if none is given.
+// Errors stopping the iteration are reported by Err.
+func (tx *Tx[K, V]) Keys(ors ...behold.Query[V]) iter.Seq[K] {
+	return func(yield func(K) bool) {
+		tx.iterate(func(k K, _ V) bool { return yield(k) }, ors)
+	}
+}
+
+// Values returns an iterator over the values matching any of the given
+// queries, or every value if none is given.
+// Errors stopping the iteration are reported by Err.
+func (tx *Tx[K, V]) Values(ors ...behold.Query[V]) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		tx.iterate(func(_ K, v V) bool { return yield(v) }, ors)
+	}
+}
+
+// iterate runs ForEachMatch recording its error for Err.
+func (tx *Tx[K, V]) iterate(fn func(K, V) bool, ors []behold.Query[V]) {
+	err := tx.ForEachMatch(fn, ors...)
+	if tx != nil {
+		tx.iterErr = err
+	}
+}
@@ -0,0 +1,41 @@
+//go:build go1.23
+
+package memstore
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/amery/behold"
+)
+
+func TestIterators(t *testing.T) {
+	s := newTestStore(t)
+
+	var leaked *Tx[string, int]
+	err := s.View(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(*Tx[string, int])
+		leaked = tx
+
+		all := maps.Collect(tx.All())
+		assert.Equal(t, map[string]int{keyOne: 1, keyTwo: 2, keyThree: 3}, all)
+		assert.NoError(t, tx.Err())
+
+		keys := slices.Sorted(tx.Keys(behold.GtQuery(1)))
+		assert.Equal(t, []string{keyThree, keyTwo}, keys)
+
+		values := slices.Sorted(tx.Values(behold.LtQuery(3)))
+		assert.Equal(t, []int{1, 2}, values)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	for range leaked.All() {
+		t.Error("iterating a closed transaction")
+	}
+	assert.ErrorIs(t, leaked.Err(), behold.ErrClosed)
+}
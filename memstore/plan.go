@@ -0,0 +1,265 @@
+package memstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amery/behold"
+)
+
+// Plan describes how ForEachMatch resolves a set of queries, either
+// scanning the whole store or combining the candidates given by its
+// indexes. Candidates are always checked against the queries, so
+// parts of a query not served by an index act as filters.
+type Plan[K comparable, V any] struct {
+	root planNode[K, V]
+}
+
+// Plan returns how ForEachMatch would resolve the given queries
+// using the indexes of the store.
+func (tx *Tx[K, V]) Plan(ors ...behold.Query[V]) (*Plan[K, V], error) {
+	if err := tx.check(false); err != nil {
+		return nil, err
+	}
+
+	s := tx.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, behold.ErrClosed
+	}
+
+	return s.plan(ors), nil
+}
+
+// Indexed tells if the plan uses indexes instead of scanning
+// the whole store.
+func (p *Plan[K, V]) Indexed() bool {
+	if p == nil {
+		return false
+	}
+
+	_, scan := p.root.(*scanNode[K, V])
+	return !scan
+}
+
+// Explain returns a human-readable description of the plan,
+// one step per line indented by depth.
+func (p *Plan[K, V]) Explain() string {
+	if p == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	p.root.explain(&sb, 0)
+	return sb.String()
+}
+
+// String returns the same as Explain.
+func (p *Plan[K, V]) String() string {
+	return p.Explain()
+}
+
+// plan decides how to resolve the given queries, any of which
+// needs to match. s.mu must be held.
+func (s *Store[K, V]) plan(ors []behold.Query[V]) *Plan[K, V] {
+	var q behold.Query[V]
+	switch len(ors) {
+	case 0:
+		return &Plan[K, V]{root: &scanNode[K, V]{}}
+	case 1:
+		q = ors[0]
+	default:
+		q = behold.MatchAny(ors...)
+	}
+
+	root := s.planQuery(q)
+	if root == nil {
+		root = &scanNode[K, V]{filter: q}
+	}
+	return &Plan[K, V]{root: root}
+}
+
+// planQuery returns how to find the candidates of a query
+// using indexes, or nil if it requires a scan. s.mu must be held.
+func (s *Store[K, V]) planQuery(q behold.Query[V]) planNode[K, V] {
+	if q == nil {
+		return nil
+	}
+
+	if lq, ok := q.(behold.LogicalQuery[V]); ok {
+		switch lq.Operator() {
+		case behold.OpAnd:
+			return s.planAnd(lq.Operands())
+		case behold.OpOr:
+			return s.planOr(lq.Operands())
+		default:
+			return nil
+		}
+	}
+
+	for _, idx := range s.indexes {
+		if idx.serves(q) {
+			return &indexNode[K, V]{idx: idx, q: q}
+		}
+	}
+	return nil
+}
+
+// planAnd intersects the candidates of the operands served by indexes,
+// leaving the rest as filters. s.mu must be held.
+func (s *Store[K, V]) planAnd(queries []behold.Query[V]) planNode[K, V] {
+	var out intersectNode[K, V]
+	for _, q := range queries {
+		switch n := s.planQuery(q); {
+		case q == nil:
+			continue
+		case n != nil:
+			out.nodes = append(out.nodes, n)
+		default:
+			out.filters = append(out.filters, q)
+		}
+	}
+
+	switch {
+	case len(out.nodes) == 0:
+		return nil
+	case len(out.nodes) == 1 && len(out.filters) == 0:
+		return out.nodes[0]
+	default:
+		return &out
+	}
+}
+
+// planOr unites the candidates of the operands, if all of them
+// are served by indexes. s.mu must be held.
+func (s *Store[K, V]) planOr(queries []behold.Query[V]) planNode[K, V] {
+	var out unionNode[K, V]
+	for _, q := range queries {
+		if q == nil {
+			continue
+		}
+
+		n := s.planQuery(q)
+		if n == nil {
+			return nil
+		}
+		out.nodes = append(out.nodes, n)
+	}
+
+	switch len(out.nodes) {
+	case 0:
+		return &noneNode[K, V]{}
+	case 1:
+		return out.nodes[0]
+	default:
+		return &out
+	}
+}
+
+// planNode is a step of a Plan.
+type planNode[K comparable, V any] interface {
+	// keys returns the candidates of the step, or nil if
+	// every entry is a candidate. s.mu must be held.
+	keys() map[K]struct{}
+
+	// explain describes the step at the given depth.
+	explain(sb *strings.Builder, depth int)
+}
+
+func explainLine(sb *strings.Builder, depth int, format string, args ...any) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	_, _ = fmt.Fprintf(sb, format, args...)
+	sb.WriteByte('\n')
+}
+
+// scanNode visits every entry of the store.
+type scanNode[K comparable, V any] struct {
+	filter behold.Query[V]
+}
+
+func (*scanNode[K, V]) keys() map[K]struct{} { return nil }
+
+func (n *scanNode[K, V]) explain(sb *strings.Builder, depth int) {
+	explainLine(sb, depth, "scan")
+	if n.filter != nil {
+		explainLine(sb, depth+1, "filter: %s", behold.DescribeQuery(n.filter))
+	}
+}
+
+// noneNode has no candidates.
+type noneNode[K comparable, V any] struct{}
+
+func (*noneNode[K, V]) keys() map[K]struct{} { return make(map[K]struct{}) }
+
+func (*noneNode[K, V]) explain(sb *strings.Builder, depth int) {
+	explainLine(sb, depth, "none")
+}
+
+// indexNode looks up the candidates of a query in an index.
+type indexNode[K comparable, V any] struct {
+	idx index[K, V]
+	q   behold.Query[V]
+}
+
+func (n *indexNode[K, V]) keys() map[K]struct{} { return n.idx.lookup(n.q) }
+
+func (n *indexNode[K, V]) explain(sb *strings.Builder, depth int) {
+	explainLine(sb, depth, "index %q: %s", n.idx.Name(), behold.DescribeQuery(n.q))
+}
+
+// intersectNode keeps the candidates common to all its steps.
+type intersectNode[K comparable, V any] struct {
+	nodes   []planNode[K, V]
+	filters []behold.Query[V]
+}
+
+func (n *intersectNode[K, V]) keys() map[K]struct{} {
+	out := n.nodes[0].keys()
+	for _, node := range n.nodes[1:] {
+		if len(out) == 0 {
+			break
+		}
+
+		keys := node.keys()
+		for k := range out {
+			if _, ok := keys[k]; !ok {
+				delete(out, k)
+			}
+		}
+	}
+	return out
+}
+
+func (n *intersectNode[K, V]) explain(sb *strings.Builder, depth int) {
+	explainLine(sb, depth, "intersect")
+	for _, node := range n.nodes {
+		node.explain(sb, depth+1)
+	}
+	for _, q := range n.filters {
+		explainLine(sb, depth+1, "filter: %s", behold.DescribeQuery(q))
+	}
+}
+
+// unionNode joins the candidates of all its steps.
+type unionNode[K comparable, V any] struct {
+	nodes []planNode[K, V]
+}
+
+func (n *unionNode[K, V]) keys() map[K]struct{} {
+	out := n.nodes[0].keys()
+	for _, node := range n.nodes[1:] {
+		for k := range node.keys() {
+			out[k] = struct{}{}
+		}
+	}
+	return out
+}
+
+func (n *unionNode[K, V]) explain(sb *strings.Builder, depth int) {
+	explainLine(sb, depth, "union")
+	for _, node := range n.nodes {
+		node.explain(sb, depth+1)
+	}
+}
@@ -0,0 +1,97 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func units(v int) int { return v % 10 }
+
+var byUnits = behold.NewAccessor(units)
+
+func isEven(v int) bool { return v%2 == 0 }
+
+func TestPlan(t *testing.T) {
+	s := newIndexedStore(t)
+	require.NoError(t, AddIndex(s, "units", byUnits))
+
+	tensGt1 := byTens.Query(behold.GtQuery(1))
+	tensLt4 := byTens.Query(behold.LtQuery(4))
+	unitsEq0 := byUnits.Query(behold.EqQuery(0))
+	even := behold.QueryFunc[int](isEven)
+
+	for _, tc := range []struct {
+		name    string
+		ors     []behold.Query[int]
+		indexed bool
+		explain string
+		keys    []string
+	}{
+		{"All", nil, false, "scan\n", []string{"a", "b", "c", "d"}},
+		{"Index", []behold.Query[int]{tensGt1}, true,
+			"index \"tens\": memstore.tens > 1\n",
+			[]string{"b", "c", "d"}},
+		{"Scan", []behold.Query[int]{even}, false,
+			"scan\n  filter: memstore.isEven\n",
+			[]string{"a", "b", "c", "d"}},
+		{"And", []behold.Query[int]{tensGt1.And(tensLt4, even)}, true,
+			"intersect\n" +
+				"  index \"tens\": memstore.tens > 1\n" +
+				"  index \"tens\": memstore.tens < 4\n" +
+				"  filter: memstore.isEven\n",
+			[]string{"b", "c"}},
+		{"Or", []behold.Query[int]{tensLt4.And(tensGt1), unitsEq0}, true,
+			"union\n" +
+				"  intersect\n" +
+				"    index \"tens\": memstore.tens < 4\n" +
+				"    index \"tens\": memstore.tens > 1\n" +
+				"  index \"units\": memstore.units == 0\n",
+			[]string{"a", "b", "c", "d"}},
+		{"OrScan", []behold.Query[int]{tensGt1, even}, false,
+			"scan\n  filter: (memstore.tens > 1 OR memstore.isEven)\n",
+			[]string{"a", "b", "c", "d"}},
+		{"Not", []behold.Query[int]{tensLt4.And(behold.Not(unitsEq0))}, true,
+			"intersect\n" +
+				"  index \"tens\": memstore.tens < 4\n" +
+				"  filter: NOT memstore.units == 0\n",
+			[]string{"a", "b", "c"}},
+		{"Xor", []behold.Query[int]{behold.Xor(tensLt4, unitsEq0)}, false,
+			"scan\n  filter: (memstore.tens < 4 XOR memstore.units == 0)\n",
+			[]string{"a", "b", "c", "d"}},
+		{"None", []behold.Query[int]{behold.MatchAny[int]()}, true,
+			"none\n", []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+				p, err := tx.(*Tx[string, int]).Plan(tc.ors...)
+				require.NoError(t, err)
+				assert.Equal(t, tc.indexed, p.Indexed())
+				assert.Equal(t, tc.explain, p.Explain())
+				return nil
+			}))
+
+			if tc.indexed {
+				assert.Equal(t, tc.keys, candidates(t, s, tc.ors...))
+			}
+		})
+	}
+}
+
+func TestPlanMatches(t *testing.T) {
+	s := newIndexedStore(t)
+	require.NoError(t, AddIndex(s, "units", byUnits))
+
+	q := byTens.Query(behold.GtQuery(1)).
+		And(byUnits.Query(behold.EqQuery(0)),
+			byTens.Query(behold.LtQuery(4)))
+
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, []string{"b", "c"}, matchKeys(t, tx, q))
+		return nil
+	}))
+}
@@ -0,0 +1,113 @@
+package memstore
+
+import (
+	"sort"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.SavepointTx[string, any] = (*Tx[string, any])(nil)
+
+// savepoint is the state of the pending changes of a Tx
+// when a behold.Savepoint was marked.
+type savepoint struct {
+	id    behold.Savepoint
+	undo  int
+	order int
+}
+
+// undo is the state of the pending change of a key before
+// being replaced while savepoints are marked.
+type undo[K comparable, V any] struct {
+	key  K
+	prev change[V]
+	had  bool
+}
+
+// Savepoint marks the current state of the pending changes, so
+// RollbackTo can discard those made afterwards.
+func (tx *Tx[K, V]) Savepoint() (behold.Savepoint, error) {
+	if err := tx.check(true); err != nil {
+		return 0, err
+	}
+
+	tx.lastSave++
+	tx.saves = append(tx.saves, savepoint{
+		id:    tx.lastSave,
+		undo:  len(tx.undo),
+		order: len(tx.order),
+	})
+	return tx.lastSave, nil
+}
+
+// RollbackTo discards the changes made after the savepoint, including
+// those of Set, Append and Delete, and forgets the savepoints marked
+// after it. The savepoint itself remains valid. Unknown or forgotten
+// savepoints fail with behold.ErrInvalid.
+func (tx *Tx[K, V]) RollbackTo(sp behold.Savepoint) error {
+	if err := tx.check(true); err != nil {
+		return err
+	}
+
+	i, err := tx.findSave(sp)
+	if err != nil {
+		return err
+	}
+
+	save := tx.saves[i]
+	for j := len(tx.undo) - 1; j >= save.undo; j-- {
+		u := tx.undo[j]
+		if u.had {
+			tx.changes[u.key] = u.prev
+		} else {
+			delete(tx.changes, u.key)
+		}
+	}
+
+	tx.undo = tx.undo[:save.undo]
+	tx.order = tx.order[:save.order]
+	tx.saves = tx.saves[:i+1]
+	return nil
+}
+
+// Release forgets the savepoint and those marked after it, keeping
+// the changes made since. Once no savepoint remains, the record needed
+// to roll back is discarded. Unknown or forgotten savepoints fail with
+// behold.ErrInvalid.
+func (tx *Tx[K, V]) Release(sp behold.Savepoint) error {
+	if err := tx.check(true); err != nil {
+		return err
+	}
+
+	i, err := tx.findSave(sp)
+	if err != nil {
+		return err
+	}
+
+	tx.saves = tx.saves[:i]
+	if i == 0 {
+		tx.undo = nil
+	}
+	return nil
+}
+
+// findSave returns the position of a savepoint.
+func (tx *Tx[K, V]) findSave(sp behold.Savepoint) (int, error) {
+	i := sort.Search(len(tx.saves), func(i int) bool { return tx.saves[i].id >= sp })
+	if i == len(tx.saves) || tx.saves[i].id != sp {
+		return 0, core.Wrapf(behold.ErrInvalid, "unknown savepoint %v", sp)
+	}
+	return i, nil
+}
+
+// saveUndo records the pending change of a key before replacing it,
+// if there are savepoints to roll back to.
+func (tx *Tx[K, V]) saveUndo(key K) {
+	if len(tx.saves) > 0 {
+		prev, had := tx.changes[key]
+		tx.undo = append(tx.undo, undo[K, V]{key: key, prev: prev, had: had})
+	}
+}
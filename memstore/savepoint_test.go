@@ -0,0 +1,144 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func getAllValues(t *testing.T, tx behold.Tx[string, int]) map[string]int {
+	t.Helper()
+
+	out := make(map[string]int)
+	require.NoError(t, tx.ForEach(func(k string, v int) bool {
+		out[k] = v
+		return true
+	}))
+	return out
+}
+
+func TestSavepoint(t *testing.T) {
+	cfg := &Config[string, int]{
+		Append: func(_ string, a, b int) (int, error) { return a + b, nil },
+	}
+	s := cfg.New()
+	defer s.Close()
+
+	ctx := context.Background()
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		return tx.Set(keyOne, 1)
+	}))
+
+	require.NoError(t, s.Update(ctx, func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.SavepointTx[string, int])
+
+		require.NoError(t, tx.Set(keyTwo, 2))
+		sp1, err := tx.Savepoint()
+		require.NoError(t, err)
+
+		require.NoError(t, tx.Append(keyOne, 10))
+		require.NoError(t, tx.Set(keyTwo, 20))
+		require.NoError(t, tx.Set(keyThree, 3))
+
+		sp2, err := tx.Savepoint()
+		require.NoError(t, err)
+		require.NoError(t, tx.Delete(keyOne))
+		assert.Equal(t, map[string]int{keyTwo: 20, keyThree: 3}, getAllValues(t, tx))
+
+		require.NoError(t, tx.RollbackTo(sp2))
+		assert.Equal(t, map[string]int{keyOne: 11, keyTwo: 20, keyThree: 3}, getAllValues(t, tx))
+
+		require.NoError(t, tx.RollbackTo(sp1))
+		assert.Equal(t, map[string]int{keyOne: 1, keyTwo: 2}, getAllValues(t, tx))
+
+		// later savepoints are forgotten, the target is kept
+		assert.ErrorIs(t, tx.RollbackTo(sp2), behold.ErrInvalid)
+		require.NoError(t, tx.Set(keyThree, 30))
+		require.NoError(t, tx.RollbackTo(sp1))
+		require.NoError(t, tx.Append(keyOne, 1))
+		return nil
+	}))
+
+	require.NoError(t, s.View(ctx, func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, map[string]int{keyOne: 2, keyTwo: 2}, getAllValues(t, tx))
+
+		_, err := tx.(behold.SavepointTx[string, int]).Savepoint()
+		assert.ErrorIs(t, err, behold.ErrReadOnlyTx)
+		return nil
+	}))
+}
+
+func TestRelease(t *testing.T) {
+	s := newTestStore(t)
+
+	require.NoError(t, s.Update(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(*Tx[string, int])
+
+		sp1, err := tx.Savepoint()
+		require.NoError(t, err)
+		require.NoError(t, tx.Set(keyOne, 10))
+
+		sp2, err := tx.Savepoint()
+		require.NoError(t, err)
+		require.NoError(t, tx.Set(keyTwo, 20))
+
+		// released changes are kept
+		require.NoError(t, tx.Release(sp2))
+		assert.ErrorIs(t, tx.RollbackTo(sp2), behold.ErrInvalid)
+		assert.ErrorIs(t, tx.Release(sp2), behold.ErrInvalid)
+		assert.Equal(t, map[string]int{keyOne: 10, keyTwo: 20, keyThree: 3}, getAllValues(t, tx))
+		assert.NotEmpty(t, tx.undo)
+
+		require.NoError(t, tx.RollbackTo(sp1))
+		require.NoError(t, tx.Set(keyTwo, 22))
+		require.NoError(t, tx.Release(sp1))
+		assert.Empty(t, tx.saves)
+		assert.Nil(t, tx.undo)
+
+		// without savepoints, nothing is recorded to undo
+		require.NoError(t, tx.Set(keyOne, 11))
+		assert.Nil(t, tx.undo)
+		return nil
+	}))
+
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, map[string]int{keyOne: 11, keyTwo: 22, keyThree: 3}, getAllValues(t, tx))
+		return nil
+	}))
+}
+
+func TestNested(t *testing.T) {
+	s := newTestStore(t)
+	errInvalid := errors.New("invalid batch")
+
+	require.NoError(t, s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		err := behold.Nested(tx, func(tx behold.Tx[string, int]) error {
+			require.NoError(t, tx.Set(keyOne, 10))
+			require.NoError(t, tx.Delete(keyTwo))
+			return errInvalid
+		})
+		assert.ErrorIs(t, err, errInvalid)
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, behold.Nested(tx, func(tx behold.Tx[string, int]) error {
+				return tx.Set(keyThree, 30)
+			}))
+		}
+
+		// the savepoints are released
+		mtx := tx.(*Tx[string, int])
+		assert.Empty(t, mtx.saves)
+		assert.Nil(t, mtx.undo)
+		return nil
+	}))
+
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, map[string]int{keyOne: 1, keyTwo: 2, keyThree: 30}, getAllValues(t, tx))
+		return nil
+	}))
+}
@@ -0,0 +1,387 @@
+package memstore
+
+import (
+	"slices"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.OrderedTx[string, any] = (*Tx[string, any])(nil)
+
+// pair is a key-value pair as seen by a transaction.
+type pair[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// bounds limits a scan to the keys from lo (inclusive) up to
+// hi (exclusive, unless hiInclusive is set). Nil bounds are open.
+type bounds[K comparable] struct {
+	lo          *K
+	hi          *K
+	hiInclusive bool
+}
+
+// Range calls fn, in ascending key order, for every entry whose key
+// is between from (inclusive) and to (exclusive), until fn returns false.
+func (tx *Tx[K, V]) Range(from, to K, fn func(key K, value V) bool) error {
+	return tx.scanOrdered(bounds[K]{lo: &from, hi: &to}, false, fn)
+}
+
+// ReverseRange calls fn, in descending key order, for every entry whose
+// key is between from (inclusive) and to (exclusive), until fn returns false.
+func (tx *Tx[K, V]) ReverseRange(from, to K, fn func(key K, value V) bool) error {
+	return tx.scanOrdered(bounds[K]{lo: &from, hi: &to}, true, fn)
+}
+
+// Seek calls fn, in ascending key order, for every entry whose key
+// isn't less than the given one, until fn returns false.
+func (tx *Tx[K, V]) Seek(key K, fn func(key K, value V) bool) error {
+	return tx.scanOrdered(bounds[K]{lo: &key}, false, fn)
+}
+
+// ReverseSeek calls fn, in descending key order, for every entry whose
+// key isn't greater than the given one, until fn returns false.
+func (tx *Tx[K, V]) ReverseSeek(key K, fn func(key K, value V) bool) error {
+	return tx.scanOrdered(bounds[K]{hi: &key, hiInclusive: true}, true, fn)
+}
+
+func (tx *Tx[K, V]) scanOrdered(b bounds[K], reverse bool, fn func(K, V) bool) error {
+	switch err := tx.check(false); {
+	case err != nil:
+		return err
+	case fn == nil:
+		return behold.ErrInvalid
+	case tx.s.keyOrder == nil:
+		return core.Wrap(behold.ErrInvalid, "store without key order")
+	}
+
+	return tx.scan(b, reverse, nil, fn)
+}
+
+// scanChunk is the number of entries of the store copied at once
+// while walking it.
+const scanChunk = 256
+
+// walker visits the entries within bounds as seen by a transaction,
+// in the store's order or reversed. Entries are copied in chunks, so
+// the store's lock isn't held while visiting them.
+type walker[K comparable, V any] struct {
+	tx      *Tx[K, V]
+	b       bounds[K]
+	reverse bool
+	ors     []behold.Query[V]
+
+	// after is the last entry of the store considered
+	// by the previous chunk.
+	after *entry[K, V]
+	count int
+}
+
+// scan calls fn for the entries within bounds as seen by the
+// transaction, in the store's order or reversed, until fn returns
+// false. If the given queries can be served by indexes, only their
+// candidates are visited.
+func (tx *Tx[K, V]) scan(b bounds[K], reverse bool, ors []behold.Query[V], fn func(K, V) bool) error {
+	w := &walker[K, V]{tx: tx, b: b, reverse: reverse, ors: ors}
+	return w.run(fn)
+}
+
+func (w *walker[K, V]) run(fn func(K, V) bool) error {
+	for {
+		pairs, more, err := w.next()
+		if err != nil {
+			return err
+		}
+
+		if ok, err := w.visit(pairs, fn); !ok || !more {
+			return err
+		}
+	}
+}
+
+// visit calls fn for each pair, telling if the walk should go on.
+func (w *walker[K, V]) visit(pairs []pair[K, V], fn func(K, V) bool) (bool, error) {
+	for _, p := range pairs {
+		if err := w.tx.checkEvery(w.count); err != nil {
+			return false, err
+		}
+		w.count++
+
+		if !fn(p.key, p.value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// next returns the following chunk, and if more could follow it.
+func (w *walker[K, V]) next() ([]pair[K, V], bool, error) {
+	s := w.tx.s
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return nil, false, behold.ErrClosed
+	}
+
+	if w.after == nil {
+		if keys := s.plan(w.ors).root.keys(); keys != nil {
+			return w.tx.scanKeys(keys), false, nil
+		}
+	}
+
+	pairs, more := w.collect()
+	return pairs, more, nil
+}
+
+// collect copies up to scanChunk entries of the store following the
+// previous chunk, and the keys added by the transaction between them.
+// s.mu must be held.
+func (w *walker[K, V]) collect() ([]pair[K, V], bool) {
+	var out []pair[K, V]
+	var last *entry[K, V]
+
+	n := w.resume()
+	for i := 0; i < scanChunk && w.inRange(n); i++ {
+		last = n.value
+		out = w.tx.appendEntry(out, last)
+		n = w.step(n)
+	}
+
+	more := w.inRange(n)
+	if !more {
+		last = nil
+	}
+
+	out = w.appendPending(out, last)
+	w.after = last
+	return out, more
+}
+
+// resume returns the first node of the chunk. s.mu must be held.
+func (w *walker[K, V]) resume() *slnode[*entry[K, V]] {
+	switch {
+	case w.after == nil && w.reverse:
+		return w.tx.last(w.b)
+	case w.after == nil:
+		return w.tx.first(w.b)
+	case w.reverse:
+		return w.skip(w.tx.s.order.SeekLast(w.after))
+	default:
+		return w.skip(w.tx.s.order.Seek(w.after))
+	}
+}
+
+// skip steps over the node of the entry visited last, if present.
+func (w *walker[K, V]) skip(n *slnode[*entry[K, V]]) *slnode[*entry[K, V]] {
+	if n != nil && w.tx.s.order.cmp(n.value, w.after) == 0 {
+		return w.step(n)
+	}
+	return n
+}
+
+// step returns the node following n in the direction of the walk.
+func (w *walker[K, V]) step(n *slnode[*entry[K, V]]) *slnode[*entry[K, V]] {
+	if w.reverse {
+		return n.Prev()
+	}
+	return n.Next()
+}
+
+// inRange tells if there is a node and it's within bounds.
+func (w *walker[K, V]) inRange(n *slnode[*entry[K, V]]) bool {
+	return n != nil && w.tx.within(w.b, n.value.key)
+}
+
+// appendPending adds to the chunk the keys stored by the transaction
+// that aren't in the store and fall between the previous chunk and
+// the given last entry of this one, nil meaning unbounded. Without a
+// key order they are all added by the final chunk. s.mu must be held.
+func (w *walker[K, V]) appendPending(out []pair[K, V], last *entry[K, V]) []pair[K, V] {
+	if w.tx.s.keyOrder == nil {
+		if last != nil {
+			return out
+		}
+		return w.tx.appendAdded(out, w.b)
+	}
+
+	if w.reverse {
+		slices.Reverse(out)
+	}
+
+	out = w.tx.appendAddedFunc(out, func(key K) bool {
+		return w.between(key, last)
+	})
+
+	if w.reverse {
+		slices.Reverse(out)
+	}
+	return out
+}
+
+// between tells if the key is within bounds, past the previous chunk
+// and not past the given last entry of this one.
+func (w *walker[K, V]) between(key K, last *entry[K, V]) bool {
+	order := w.tx.s.keyOrder
+
+	dir := 1
+	if w.reverse {
+		dir = -1
+	}
+
+	switch {
+	case !w.tx.within(w.b, key):
+		return false
+	case w.after != nil && dir*order(key, w.after.key) <= 0:
+		return false
+	default:
+		return last == nil || dir*order(key, last.key) <= 0
+	}
+}
+
+// within tells if the key is within both bounds.
+func (tx *Tx[K, V]) within(b bounds[K], key K) bool {
+	return tx.aboveLo(b, key) && tx.belowHi(b, key)
+}
+
+// scanLocked returns the entries within bounds as seen by the
+// transaction, in the store's order. Bounds are only considered if
+// the store has a key order. If the given queries can be served by
+// indexes, only their candidates are returned. s.mu must be held.
+func (tx *Tx[K, V]) scanLocked(b bounds[K], ors []behold.Query[V]) []pair[K, V] {
+	if keys := tx.s.plan(ors).root.keys(); keys != nil {
+		return tx.scanKeys(keys)
+	}
+
+	var out []pair[K, V]
+	for n := tx.first(b); n != nil; n = n.Next() {
+		e := n.value
+		if !tx.belowHi(b, e.key) {
+			break
+		}
+
+		out = tx.appendEntry(out, e)
+	}
+
+	return tx.appendAdded(out, b)
+}
+
+// scanKeys returns the given keys, and those changed by the transaction,
+// as seen by the transaction in the store's order. s.mu must be held.
+func (tx *Tx[K, V]) scanKeys(keys map[K]struct{}) []pair[K, V] {
+	for _, key := range tx.order {
+		keys[key] = struct{}{}
+	}
+
+	entries := make([]*entry[K, V], 0, len(keys))
+	for key := range keys {
+		if e, ok := tx.s.entries[key]; ok {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, tx.s.order.cmp)
+
+	out := make([]pair[K, V], 0, len(entries))
+	for _, e := range entries {
+		out = tx.appendEntry(out, e)
+	}
+
+	return tx.appendAdded(out, bounds[K]{})
+}
+
+// appendEntry adds the entry as seen by the transaction, if present.
+func (tx *Tx[K, V]) appendEntry(out []pair[K, V], e *entry[K, V]) []pair[K, V] {
+	if c, ok := tx.changes[e.key]; ok {
+		if !c.deleted {
+			out = append(out, pair[K, V]{e.key, c.value})
+		}
+	} else if value, ok := tx.at(e); ok {
+		out = append(out, pair[K, V]{e.key, value})
+	}
+	return out
+}
+
+// first returns the first node of the store within the lower bound.
+// s.mu must be held.
+func (tx *Tx[K, V]) first(b bounds[K]) *slnode[*entry[K, V]] {
+	if b.lo == nil || tx.s.keyOrder == nil {
+		return tx.s.order.First()
+	}
+	return tx.s.order.Seek(&entry[K, V]{key: *b.lo})
+}
+
+// last returns the last node of the store within the upper bound.
+// s.mu must be held.
+func (tx *Tx[K, V]) last(b bounds[K]) *slnode[*entry[K, V]] {
+	if b.hi == nil || tx.s.keyOrder == nil {
+		return tx.s.order.Last()
+	}
+
+	n := tx.s.order.SeekLast(&entry[K, V]{key: *b.hi})
+	if n != nil && !tx.belowHi(b, n.value.key) {
+		n = n.Prev()
+	}
+	return n
+}
+
+// belowHi tells if the key is within the upper bound.
+func (tx *Tx[K, V]) belowHi(b bounds[K], key K) bool {
+	if b.hi == nil || tx.s.keyOrder == nil {
+		return true
+	}
+
+	c := tx.s.keyOrder(key, *b.hi)
+	return c < 0 || (c == 0 && b.hiInclusive)
+}
+
+// appendAdded adds the keys within bounds stored by this transaction
+// that aren't in the store, keeping the store's order. s.mu must be held.
+func (tx *Tx[K, V]) appendAdded(out []pair[K, V], b bounds[K]) []pair[K, V] {
+	return tx.appendAddedFunc(out, func(key K) bool {
+		return tx.within(b, key)
+	})
+}
+
+// appendAddedFunc adds the keys accepted by keep stored by this
+// transaction that aren't in the store, keeping the store's order.
+// s.mu must be held.
+func (tx *Tx[K, V]) appendAddedFunc(out []pair[K, V], keep func(K) bool) []pair[K, V] {
+	n := len(out)
+	for _, key := range tx.order {
+		if _, ok := tx.s.entries[key]; ok {
+			continue
+		}
+
+		c := tx.changes[key]
+		if !c.deleted && keep(key) {
+			out = append(out, pair[K, V]{key, c.value})
+		}
+	}
+
+	if len(out) > n {
+		tx.sortPairs(out)
+	}
+	return out
+}
+
+// sortPairs sorts pairs by key, if the store has a key order.
+func (tx *Tx[K, V]) sortPairs(out []pair[K, V]) {
+	if order := tx.s.keyOrder; order != nil {
+		slices.SortStableFunc(out, func(a, b pair[K, V]) int {
+			return order(a.key, b.key)
+		})
+	}
+}
+
+// aboveLo tells if the key is within the lower bound.
+func (tx *Tx[K, V]) aboveLo(b bounds[K], key K) bool {
+	if b.lo == nil || tx.s.keyOrder == nil {
+		return true
+	}
+	return tx.s.keyOrder(key, *b.lo) >= 0
+}
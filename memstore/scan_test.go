@@ -0,0 +1,185 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+func newOrderedStore(t *testing.T, keys ...string) *Store[string, int] {
+	t.Helper()
+
+	cfg := &Config[string, int]{KeyOrder: cmp.Compare[string]}
+	s := cfg.New()
+	t.Cleanup(func() { _ = s.Close() })
+
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		for i, k := range keys {
+			if err := tx.Set(k, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func collectKeys(t *testing.T, scan func(fn func(string, int) bool) error) []string {
+	t.Helper()
+
+	var keys []string
+	err := scan(func(k string, _ int) bool {
+		keys = append(keys, k)
+		return true
+	})
+	require.NoError(t, err)
+	return keys
+}
+
+func TestRange(t *testing.T) {
+	s := newOrderedStore(t, "d", "b", "e", "a", "c")
+
+	err := s.View(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.OrderedTx[string, int])
+
+		assert.Equal(t, []string{"a", "b", "c", "d", "e"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ForEachMatch(fn)
+		}))
+		assert.Equal(t, []string{"b", "c", "d"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.Range("b", "e", fn)
+		}))
+		assert.Equal(t, []string{"d", "c", "b"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ReverseRange("b", "e", fn)
+		}))
+		assert.Equal(t, []string{"c", "d", "e"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.Seek("bb", fn)
+		}))
+		assert.Equal(t, []string{"c", "b", "a"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ReverseSeek("c", fn)
+		}))
+		assert.Empty(t, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.Range("e", "b", fn)
+		}))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestRangePending(t *testing.T) {
+	s := newOrderedStore(t, "b", "d", "f")
+
+	err := s.Update(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.OrderedTx[string, int])
+
+		require.NoError(t, tx.Set("e", 10))
+		require.NoError(t, tx.Set("a", 10))
+		require.NoError(t, tx.Delete("d"))
+
+		assert.Equal(t, []string{"a", "b", "e", "f"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ForEachMatch(fn)
+		}))
+		assert.Equal(t, []string{"e", "b"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ReverseRange("b", "f", fn)
+		}))
+		return nil
+	})
+	require.NoError(t, err)
+
+	err = s.View(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.OrderedTx[string, int])
+		assert.Equal(t, []string{"b", "e"}, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.Range("b", "f", fn)
+		}))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestInsertionOrder(t *testing.T) {
+	s := New[string, int]()
+	defer s.Close()
+
+	keys := []string{"d", "b", "e", "a", "c"}
+	for _, k := range keys {
+		err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+			return tx.Set(k, 0)
+		})
+		require.NoError(t, err)
+	}
+
+	err := s.View(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.OrderedTx[string, int])
+
+		assert.Equal(t, keys, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ForEachMatch(fn)
+		}))
+
+		err := tx.Range("a", "c", func(string, int) bool { return true })
+		assert.ErrorIs(t, err, behold.ErrInvalid)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestRangeChunks(t *testing.T) {
+	const n = 3*scanChunk + 10
+
+	var keys []string
+	for i := 0; i < n; i += 2 {
+		keys = append(keys, fmt.Sprintf("k%04d", i))
+	}
+	s := newOrderedStore(t, keys...)
+
+	err := s.Update(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.OrderedTx[string, int])
+
+		// pending keys interleaved with the stored ones
+		var want []string
+		for i := 0; i < n; i++ {
+			k := fmt.Sprintf("k%04d", i)
+			if i%2 == 1 {
+				require.NoError(t, tx.Set(k, i))
+			}
+			want = append(want, k)
+		}
+
+		assert.Equal(t, want, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ForEachMatch(fn)
+		}))
+
+		slices.Reverse(want)
+		assert.Equal(t, want, collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ReverseSeek(want[0], fn)
+		}))
+		assert.Equal(t, want[1:], collectKeys(t, func(fn func(string, int) bool) error {
+			return tx.ReverseRange(want[n-1], want[0], fn)
+		}))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestRangeUnlocked(t *testing.T) {
+	s := newOrderedStore(t, "a", "b", "c")
+
+	err := s.View(context.Background(), func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.OrderedTx[string, int])
+
+		// writers aren't blocked while visiting entries
+		return tx.Range("a", "z", func(k string, _ int) bool {
+			err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+				return tx.Set(k+k, 0)
+			})
+			return assert.NoError(t, err)
+		})
+	})
+	require.NoError(t, err)
+}
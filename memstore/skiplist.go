@@ -0,0 +1,161 @@
+package memstore
+
+const skiplistMaxLevel = 24
+
+// skiplist is a sorted list of unique values supporting logarithmic
+// insertion, removal and search, and walking in both directions.
+type skiplist[T any] struct {
+	cmp   func(a, b T) int
+	head  slnode[T]
+	tail  *slnode[T]
+	level int
+	len   int
+	seed  uint64
+}
+
+type slnode[T any] struct {
+	value T
+	prev  *slnode[T]
+	next  []*slnode[T]
+}
+
+func newSkiplist[T any](cmp func(a, b T) int) *skiplist[T] {
+	sl := &skiplist[T]{
+		cmp:   cmp,
+		level: 1,
+		seed:  0x9e3779b97f4a7c15,
+	}
+	sl.head.next = make([]*slnode[T], skiplistMaxLevel)
+	return sl
+}
+
+// randomLevel returns the level of a new node, each level
+// being half as likely as the previous.
+func (sl *skiplist[T]) randomLevel() int {
+	// xorshift64
+	sl.seed ^= sl.seed << 13
+	sl.seed ^= sl.seed >> 7
+	sl.seed ^= sl.seed << 17
+
+	level := 1
+	for x := sl.seed; x&1 == 1 && level < skiplistMaxLevel; x >>= 1 {
+		level++
+	}
+	return level
+}
+
+// path finds the last node before v on every level.
+func (sl *skiplist[T]) path(v T) [skiplistMaxLevel]*slnode[T] {
+	var update [skiplistMaxLevel]*slnode[T]
+
+	n := &sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for n.next[i] != nil && sl.cmp(n.next[i].value, v) < 0 {
+			n = n.next[i]
+		}
+		update[i] = n
+	}
+	return update
+}
+
+// Insert adds a value to the list, replacing an equal one if present.
+func (sl *skiplist[T]) Insert(v T) {
+	update := sl.path(v)
+	if n := update[0].next[0]; n != nil && sl.cmp(n.value, v) == 0 {
+		n.value = v
+		return
+	}
+
+	level := sl.randomLevel()
+	for i := sl.level; i < level; i++ {
+		update[i] = &sl.head
+	}
+	if level > sl.level {
+		sl.level = level
+	}
+
+	n := &slnode[T]{value: v, next: make([]*slnode[T], level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+	}
+
+	if update[0] != &sl.head {
+		n.prev = update[0]
+	}
+	if n.next[0] != nil {
+		n.next[0].prev = n
+	} else {
+		sl.tail = n
+	}
+	sl.len++
+}
+
+// Remove removes the value equal to v, if present.
+func (sl *skiplist[T]) Remove(v T) bool {
+	update := sl.path(v)
+
+	n := update[0].next[0]
+	if n == nil || sl.cmp(n.value, v) != 0 {
+		return false
+	}
+
+	for i := range n.next {
+		update[i].next[i] = n.next[i]
+	}
+
+	if n.next[0] != nil {
+		n.next[0].prev = n.prev
+	} else {
+		sl.tail = n.prev
+	}
+
+	for sl.level > 1 && sl.head.next[sl.level-1] == nil {
+		sl.level--
+	}
+	sl.len--
+	return true
+}
+
+// First returns the first node of the list, or nil if empty.
+func (sl *skiplist[T]) First() *slnode[T] {
+	return sl.head.next[0]
+}
+
+// Last returns the last node of the list, or nil if empty.
+func (sl *skiplist[T]) Last() *slnode[T] {
+	return sl.tail
+}
+
+// Seek returns the first node not less than v, or nil if none.
+func (sl *skiplist[T]) Seek(v T) *slnode[T] {
+	return sl.path(v)[0].next[0]
+}
+
+// SeekLast returns the last node not greater than v, or nil if none.
+func (sl *skiplist[T]) SeekLast(v T) *slnode[T] {
+	n := sl.Seek(v)
+	switch {
+	case n == nil:
+		return sl.tail
+	case sl.cmp(n.value, v) == 0:
+		return n
+	default:
+		return n.prev
+	}
+}
+
+// Len returns the number of values in the list.
+func (sl *skiplist[T]) Len() int {
+	return sl.len
+}
+
+// Next returns the following node, or nil if it's the last.
+func (n *slnode[T]) Next() *slnode[T] {
+	return n.next[0]
+}
+
+// Prev returns the preceding node, or nil if it's the first.
+func (n *slnode[T]) Prev() *slnode[T] {
+	return n.prev
+}
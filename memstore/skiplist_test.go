@@ -0,0 +1,61 @@
+package memstore
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func skiplistValues(sl *skiplist[int]) (forward, backward []int) {
+	for n := sl.First(); n != nil; n = n.Next() {
+		forward = append(forward, n.value)
+	}
+	for n := sl.Last(); n != nil; n = n.Prev() {
+		backward = append(backward, n.value)
+	}
+	return forward, backward
+}
+
+func TestSkiplist(t *testing.T) {
+	sl := newSkiplist(cmp.Compare[int])
+	rng := rand.New(rand.NewSource(1))
+
+	var want []int
+	for _, v := range rng.Perm(1000) {
+		sl.Insert(v * 2)
+		want = append(want, v*2)
+	}
+	sl.Insert(10) // duplicate
+	slices.Sort(want)
+
+	forward, backward := skiplistValues(sl)
+	assert.Equal(t, want, forward)
+	slices.Reverse(backward)
+	assert.Equal(t, want, backward)
+	assert.Equal(t, len(want), sl.Len())
+
+	assert.Equal(t, 10, sl.Seek(9).value)
+	assert.Equal(t, 10, sl.Seek(10).value)
+	assert.Equal(t, 8, sl.SeekLast(9).value)
+	assert.Equal(t, 10, sl.SeekLast(10).value)
+	assert.Nil(t, sl.Seek(2000))
+	assert.Equal(t, 1998, sl.SeekLast(5000).value)
+	assert.Nil(t, sl.SeekLast(-1))
+
+	for _, v := range rng.Perm(1000) {
+		if v%3 == 0 {
+			assert.True(t, sl.Remove(v*2))
+		}
+	}
+	assert.False(t, sl.Remove(1))
+
+	want = slices.DeleteFunc(want, func(v int) bool { return (v/2)%3 == 0 })
+	forward, backward = skiplistValues(sl)
+	assert.Equal(t, want, forward)
+	slices.Reverse(backward)
+	assert.Equal(t, want, backward)
+	assert.Equal(t, len(want), sl.Len())
+}
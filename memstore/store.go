@@ -0,0 +1,237 @@
+package memstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"darvaza.org/core"
+
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.SnapshotStore[string, any] = (*Store[string, any])(nil)
+
+// Store is an in-memory behold.Store keeping multiple versions of
+// its data. Every transaction works on the version it started at, so
+// readers never block writers and see a stable snapshot until they end.
+// Read-write transactions run concurrently, and fail to commit with a
+// behold.KeyError wrapping behold.ErrConflict if a key they read was
+// changed by another one committed after they started. Records are
+// reclaimed once no open transaction can see them.
+//
+// Entries are visited sorted by key if the store has a key order, or
+// in the order they were first stored otherwise.
+type Store[K comparable, V any] struct {
+	mu      sync.RWMutex
+	entries map[K]*entry[K, V]
+	order   *skiplist[*entry[K, V]]
+	garbage map[*entry[K, V]]struct{}
+	indexes []index[K, V]
+	pins    map[uint64]int
+	minPin  uint64
+	version uint64
+	floor   uint64
+	seq     uint64
+	closed  bool
+
+	keyOrder behold.CompFunc[K]
+	retain   uint64
+	now      func() time.Time
+	appendFn func(K, V, V) (V, error)
+	onLeak   func(behold.TxLeak)
+	leakTTL  time.Duration
+}
+
+// Version returns the version of the last committed Update.
+func (s *Store[K, V]) Version() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.version
+}
+
+// Now returns the store's current time reference.
+func (s *Store[K, V]) Now() time.Time {
+	if s == nil {
+		return time.Now()
+	}
+	return s.now()
+}
+
+// View executes a read-only transaction, holding the given locks
+// while fn runs. It gives up waiting for the locks if the context
+// ends first.
+func (s *Store[K, V]) View(ctx context.Context, fn func(behold.Tx[K, V]) error, locks ...behold.Mutex) error {
+	if err := s.checkRun(ctx, fn); err != nil {
+		return err
+	}
+
+	unlock, err := lockAll(ctx, locks)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx, err := s.begin(ctx, false)
+	if err != nil {
+		return err
+	}
+	defer tx.release()
+
+	return fn(tx)
+}
+
+// ViewAt executes a read-only transaction on the data as of the given
+// version, holding the given locks while fn runs. Past versions are
+// available while open transactions use them, and for as many versions
+// as Config.Retain says, failing with ErrSnapshotExpired otherwise.
+func (s *Store[K, V]) ViewAt(ctx context.Context, version uint64, fn func(behold.Tx[K, V]) error,
+	locks ...behold.Mutex) error {
+	if err := s.checkRun(ctx, fn); err != nil {
+		return err
+	}
+
+	unlock, err := lockAll(ctx, locks)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx, err := s.beginAt(ctx, version)
+	if err != nil {
+		return err
+	}
+	defer tx.release()
+
+	return fn(tx)
+}
+
+// Update executes a read-write transaction, holding the given locks
+// while fn runs. Changes are committed if fn returns nil without
+// having closed the transaction, and discarded otherwise, or if the
+// context ends first. Committing fails with a behold.KeyError
+// wrapping behold.ErrConflict if a key read by the transaction was
+// changed by another one meanwhile. It gives up waiting for the
+// locks if the context ends first.
+func (s *Store[K, V]) Update(ctx context.Context, fn func(behold.Tx[K, V]) error, locks ...behold.Mutex) error {
+	if err := s.checkRun(ctx, fn); err != nil {
+		return err
+	}
+
+	unlock, err := lockAll(ctx, locks)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	tx, err := s.begin(ctx, true)
+	if err != nil {
+		return err
+	}
+	defer tx.release()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.commitIfOpen()
+}
+
+// Close closes the store. Any further View or Update, or operation
+// on transactions still open, will fail with ErrClosed.
+func (s *Store[K, V]) Close() error {
+	if s == nil {
+		return behold.ErrNilReceiver
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return behold.ErrClosed
+	}
+
+	s.closed = true
+	s.entries = nil
+	s.order = nil
+	s.garbage = nil
+	s.indexes = nil
+	return nil
+}
+
+// begin starts a new transaction pinning the current version.
+func (s *Store[K, V]) begin(ctx context.Context, writable bool) (*Tx[K, V], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, behold.ErrClosed
+	}
+
+	s.pin(s.version)
+	return s.newTx(ctx, writable), nil
+}
+
+// beginAt starts a new read-only transaction pinning a past version.
+func (s *Store[K, V]) beginAt(ctx context.Context, version uint64) (*Tx[K, V], error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.closed:
+		return nil, behold.ErrClosed
+	case version > s.version:
+		return nil, core.Wrapf(behold.ErrInvalid, "version %v not committed", version)
+	case version < s.floor:
+		return nil, behold.ErrSnapshotExpired
+	}
+
+	s.pin(version)
+	tx := s.newTx(ctx, false)
+	tx.version = version
+	return tx, nil
+}
+
+func (s *Store[K, V]) checkRun(ctx context.Context, fn func(behold.Tx[K, V]) error) error {
+	switch {
+	case s == nil:
+		return behold.ErrNilReceiver
+	case ctx == nil, fn == nil:
+		return behold.ErrInvalid
+	default:
+		return ctx.Err()
+	}
+}
+
+// lockAll acquires the given locks in order, skipping nil entries,
+// and returns a function releasing them in reverse order. If the
+// context ends first, the locks already acquired are released and
+// the context's error returned.
+func lockAll(ctx context.Context, locks []behold.Mutex) (func(), error) {
+	held := make([]behold.Mutex, 0, len(locks))
+	unlock := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+
+	for _, m := range locks {
+		if m == nil {
+			continue
+		}
+
+		if err := behold.LockContext(ctx, m); err != nil {
+			unlock()
+			return nil, err
+		}
+		held = append(held, m)
+	}
+
+	return unlock, nil
+}
@@ -0,0 +1,291 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+	"github.com/amery/behold/storetest"
+)
+
+const (
+	keyOne   = "one"
+	keyTwo   = "two"
+	keyThree = "three"
+)
+
+func newTestStore(t *testing.T) *Store[string, int] {
+	t.Helper()
+
+	s := New[string, int]()
+	t.Cleanup(func() { _ = s.Close() })
+
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set(keyOne, 1))
+		require.NoError(t, tx.Set(keyTwo, 2))
+		require.NoError(t, tx.Set(keyThree, 3))
+		return nil
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestUpdateVersion(t *testing.T) {
+	s := newTestStore(t)
+	assert.Equal(t, uint64(1), s.Version())
+
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, uint64(1), tx.Version())
+		return tx.Commit()
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), s.Version())
+
+	errAbort := errors.New("abort")
+	err = s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set(keyOne, 100))
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+	assert.Equal(t, uint64(2), s.Version())
+
+	err = s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		v, err := tx.Get(keyOne)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, v)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestUpdateClose(t *testing.T) {
+	s := newTestStore(t)
+
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Delete(keyOne))
+		require.NoError(t, tx.Close())
+		assert.ErrorIs(t, tx.Set(keyTwo, 20), behold.ErrClosed)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), s.Version())
+}
+
+func TestViewReadOnly(t *testing.T) {
+	s := newTestStore(t)
+
+	err := s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.ErrorIs(t, tx.Set(keyOne, 10), behold.ErrReadOnlyTx)
+		assert.ErrorIs(t, tx.Append(keyOne, 10), behold.ErrReadOnlyTx)
+		assert.ErrorIs(t, tx.Delete(keyOne), behold.ErrReadOnlyTx)
+		assert.ErrorIs(t, tx.Commit(), behold.ErrReadOnlyTx)
+
+		_, err := tx.Get("four")
+		assert.ErrorIs(t, err, behold.ErrNotFound)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestForEach(t *testing.T) {
+	s := newTestStore(t)
+
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Delete(keyOne))
+		require.NoError(t, tx.Set("four", 4))
+
+		got := make(map[string]int)
+		q := behold.QueryFunc[any](func(v any) bool { return v.(int) > 2 })
+		err := tx.ForEach(func(k string, v int) bool {
+			got[k] = v
+			return true
+		}, q)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{keyThree: 3, "four": 4}, got)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAppend(t *testing.T) {
+	cfg := &Config[string, int]{
+		Append: func(_ string, a, b int) (int, error) { return a + b, nil },
+	}
+	s := cfg.New()
+	defer s.Close()
+
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Append(keyOne, 1))
+		require.NoError(t, tx.Append(keyOne, 2))
+
+		v, err := tx.Get(keyOne)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, v)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// without Append function
+	s2 := newTestStore(t)
+	err = s2.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.ErrorIs(t, tx.Append(keyOne, 1), behold.ErrInvalid)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestClosed(t *testing.T) {
+	s := newTestStore(t)
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Close(), behold.ErrClosed)
+
+	fn := func(behold.Tx[string, int]) error { return nil }
+	assert.ErrorIs(t, s.View(context.Background(), fn), behold.ErrClosed)
+	assert.ErrorIs(t, s.Update(context.Background(), fn), behold.ErrClosed)
+}
+
+func TestSnapshotIsolation(t *testing.T) {
+	s := newTestStore(t)
+
+	started := make(chan struct{})
+	updated := make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		done <- s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+			close(started)
+			<-updated
+
+			assert.Equal(t, uint64(1), tx.Version())
+
+			v, err := tx.Get(keyOne)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, v)
+
+			_, err = tx.Get("four")
+			assert.ErrorIs(t, err, behold.ErrNotFound)
+			return nil
+		})
+	}()
+
+	<-started
+	// the open View must not block writers
+	err := s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		require.NoError(t, tx.Set(keyOne, 10))
+		require.NoError(t, tx.Set("four", 4))
+		return tx.Delete(keyTwo)
+	})
+	require.NoError(t, err)
+	close(updated)
+	require.NoError(t, <-done)
+
+	err = s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		assert.Equal(t, uint64(2), tx.Version())
+
+		v, err := tx.Get(keyOne)
+		assert.NoError(t, err)
+		assert.Equal(t, 10, v)
+
+		_, err = tx.Get(keyTwo)
+		assert.ErrorIs(t, err, behold.ErrNotFound)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestReclaim(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	done := make(chan error)
+
+	go func() {
+		done <- s.View(ctx, func(behold.Tx[string, int]) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	for i := 0; i < 3; i++ {
+		err := s.Update(ctx, func(tx behold.Tx[string, int]) error {
+			require.NoError(t, tx.Set(keyOne, 10+i))
+			return tx.Delete(keyTwo)
+		})
+		if i == 0 {
+			require.NoError(t, err)
+		} else {
+			require.ErrorIs(t, err, behold.ErrNotFound)
+		}
+	}
+
+	s.mu.RLock()
+	assert.Len(t, s.entries[keyOne].history, 2)
+	assert.Len(t, s.entries[keyTwo].history, 2)
+	s.mu.RUnlock()
+
+	close(release)
+	require.NoError(t, <-done)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	assert.Len(t, s.entries[keyOne].history, 1)
+	assert.NotContains(t, s.entries, keyTwo)
+	assert.Equal(t, len(s.entries), s.order.Len())
+	assert.Empty(t, s.garbage)
+	assert.Empty(t, s.pins)
+}
+
+func TestConformance(t *testing.T) {
+	storetest.RunStoreTests(t, func(*testing.T) behold.Store[string, int] {
+		return New[string, int]()
+	})
+}
+
+func TestReclaimHorizon(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	old, err := s.Begin(ctx, false)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		return tx.Set(keyOne, 10)
+	}))
+
+	newer, err := s.Begin(ctx, false)
+	require.NoError(t, err)
+
+	s.mu.RLock()
+	assert.Equal(t, uint64(1), s.minPin)
+	assert.Equal(t, uint64(1), s.horizon())
+	s.mu.RUnlock()
+
+	// releasing a newer pin doesn't move the horizon
+	require.NoError(t, s.View(ctx, func(behold.Tx[string, int]) error { return nil }))
+	s.mu.RLock()
+	assert.Equal(t, uint64(1), s.floor)
+	assert.Len(t, s.garbage, 1)
+	s.mu.RUnlock()
+
+	require.NoError(t, old.Close())
+	s.mu.RLock()
+	assert.Equal(t, uint64(2), s.minPin)
+	assert.Equal(t, uint64(2), s.floor)
+	assert.Empty(t, s.garbage)
+	s.mu.RUnlock()
+
+	require.NoError(t, newer.Close())
+	s.mu.RLock()
+	assert.Empty(t, s.pins)
+	s.mu.RUnlock()
+}
@@ -0,0 +1,444 @@
+package memstore
+
+import (
+	"context"
+	"runtime"
+	"time"
+
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.Tx[string, any] = (*Tx[string, any])(nil)
+
+// Tx is a transaction on a memstore Store. It sees the data as of the
+// version it started at, and changes made by a read-write Tx are kept
+// aside until committed.
+// A Tx must not be used concurrently nor after its View or Update returns.
+//
+// Operations fail with the error of the transaction's context once it
+// ends, and scans check it periodically while visiting entries.
+// A read-write Tx is then rolled back, discarding its changes.
+//
+// The range scans of behold.OrderedTx fail with ErrInvalid if the
+// store has no key order.
+type Tx[K comparable, V any] struct {
+	s   *Store[K, V]
+	ctx context.Context
+	now time.Time
+
+	version  uint64
+	writable bool
+	done     bool
+	err      error
+
+	changes map[K]change[V]
+	order   []K
+	reads   map[K]struct{}
+	iterErr error
+
+	saves    []savepoint
+	undo     []undo[K, V]
+	lastSave behold.Savepoint
+
+	// set by Begin
+	unlock func()
+	watch  *leakWatch
+}
+
+// change is a pending modification of a key in a read-write Tx.
+type change[V any] struct {
+	value   V
+	deleted bool
+}
+
+func (s *Store[K, V]) newTx(ctx context.Context, writable bool) *Tx[K, V] {
+	tx := &Tx[K, V]{
+		s:        s,
+		ctx:      ctx,
+		now:      s.now(),
+		version:  s.version,
+		writable: writable,
+	}
+
+	if writable {
+		tx.changes = make(map[K]change[V])
+		tx.reads = make(map[K]struct{})
+	}
+
+	return tx
+}
+
+// Context returns the transaction's context.
+func (tx *Tx[K, V]) Context() context.Context {
+	if tx == nil {
+		return context.Background()
+	}
+	return tx.ctx
+}
+
+// Version returns the data version the transaction started at.
+func (tx *Tx[K, V]) Version() uint64 {
+	if tx == nil {
+		return 0
+	}
+	return tx.version
+}
+
+// Now returns the time reference taken when the transaction started.
+func (tx *Tx[K, V]) Now() time.Time {
+	if tx == nil {
+		return time.Time{}
+	}
+	return tx.now
+}
+
+// ForEach calls fn for every entry whose value matches any of the given
+// queries, or for every entry if none is given, until fn returns false.
+// Queries receive the value as their argument.
+func (tx *Tx[K, V]) ForEach(fn func(key K, value V) bool, ors ...behold.Query[any]) error {
+	var match func(K, V) bool
+	if len(ors) > 0 {
+		q := behold.MatchAny(ors...)
+		match = func(_ K, v V) bool { return q.Match(v) }
+	}
+
+	return tx.forEach(fn, match, nil)
+}
+
+// ForEachMatch calls fn for every entry whose value matches any of the
+// given queries, or for every entry if none is given, until fn returns
+// false. Queries served by the store's indexes only visit the
+// candidates the indexes return.
+func (tx *Tx[K, V]) ForEachMatch(fn func(key K, value V) bool, ors ...behold.Query[V]) error {
+	var match func(K, V) bool
+	if len(ors) > 0 {
+		q := behold.MatchAny(ors...)
+		match = func(_ K, v V) bool { return q.Match(v) }
+	}
+
+	return tx.forEach(fn, match, ors)
+}
+
+// ForEachEntry calls fn for every entry matching any of the given
+// queries, or for every entry if none is given, until fn returns false.
+func (tx *Tx[K, V]) ForEachEntry(fn func(key K, value V) bool, ors ...behold.Query[behold.Entry[K, V]]) error {
+	var match func(K, V) bool
+	if len(ors) > 0 {
+		q := behold.MatchAny(ors...)
+		match = func(k K, v V) bool {
+			return q.Match(behold.Entry[K, V]{Key: k, Value: v})
+		}
+	}
+
+	return tx.forEach(fn, match, nil)
+}
+
+// forEach calls fn for every entry accepted by match, or every entry
+// if match is nil, until fn returns false. If the value queries
+// behind match are given, indexes are used to reduce the entries
+// considered.
+func (tx *Tx[K, V]) forEach(fn func(K, V) bool, match func(K, V) bool, ors []behold.Query[V]) error {
+	switch err := tx.check(false); {
+	case err != nil:
+		return err
+	case fn == nil:
+		return behold.ErrInvalid
+	}
+
+	return tx.scan(bounds[K]{}, false, ors, func(k K, v V) bool {
+		if match != nil && !match(k, v) {
+			return true
+		}
+		return fn(k, v)
+	})
+}
+
+// Err returns the error that stopped the last iterator
+// returned by All, Keys or Values, if any.
+func (tx *Tx[K, V]) Err() error {
+	if tx == nil {
+		return behold.ErrNilReceiver
+	}
+	return tx.iterErr
+}
+
+// Get returns the value associated to a key. If the key doesn't
+// exist it fails with a behold.KeyError wrapping behold.ErrNotFound.
+func (tx *Tx[K, V]) Get(key K) (V, error) {
+	var zero V
+
+	if err := tx.check(false); err != nil {
+		return zero, err
+	}
+
+	switch value, ok, err := tx.get(key); {
+	case err != nil:
+		return zero, err
+	case !ok:
+		return zero, behold.NewNotFoundError(key)
+	default:
+		return value, nil
+	}
+}
+
+// get returns the value of a key as seen by the transaction.
+func (tx *Tx[K, V]) get(key K) (V, bool, error) {
+	if c, ok := tx.changes[key]; ok {
+		return c.value, !c.deleted, nil
+	}
+
+	s := tx.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		var zero V
+		return zero, false, behold.ErrClosed
+	}
+
+	value, ok := tx.getLocked(key)
+	return value, ok, nil
+}
+
+// getLocked returns the value of a key as seen by the transaction.
+// s.mu must be held.
+func (tx *Tx[K, V]) getLocked(key K) (V, bool) {
+	if c, ok := tx.changes[key]; ok {
+		return c.value, !c.deleted
+	}
+
+	tx.markRead(key)
+	if e, ok := tx.s.entries[key]; ok {
+		return e.at(tx.version)
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Set associates a value with a key.
+func (tx *Tx[K, V]) Set(key K, value V) error {
+	if err := tx.check(true); err != nil {
+		return err
+	}
+
+	tx.setChange(key, change[V]{value: value})
+	return nil
+}
+
+// setChange records a pending change of a key.
+func (tx *Tx[K, V]) setChange(key K, c change[V]) {
+	tx.saveUndo(key)
+	if _, ok := tx.changes[key]; !ok {
+		tx.order = append(tx.order, key)
+	}
+	tx.changes[key] = c
+}
+
+// Append combines a value with the current one of the key using
+// the store's Append function. If the key doesn't exist, Append
+// behaves like Set.
+func (tx *Tx[K, V]) Append(key K, value V) error {
+	if err := tx.check(true); err != nil {
+		return err
+	}
+
+	current, ok, err := tx.get(key)
+	switch {
+	case err != nil:
+		return err
+	case ok:
+		if tx.s.appendFn == nil {
+			return behold.ErrInvalid
+		}
+
+		v, err := tx.s.appendFn(key, current, value)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+
+	tx.setChange(key, change[V]{value: value})
+	return nil
+}
+
+// Delete removes a key and its value. If the key doesn't exist
+// it fails with a behold.KeyError wrapping behold.ErrNotFound.
+func (tx *Tx[K, V]) Delete(key K) error {
+	if err := tx.check(true); err != nil {
+		return err
+	}
+
+	switch _, ok, err := tx.get(key); {
+	case err != nil:
+		return err
+	case !ok:
+		return behold.NewNotFoundError(key)
+	}
+
+	tx.setChange(key, change[V]{deleted: true})
+	return nil
+}
+
+// Commit applies the changes of a read-write transaction to the store
+// and closes it. It fails with a behold.KeyError wrapping
+// behold.ErrConflict if a key read by the transaction was changed by
+// another one committed after it started.
+func (tx *Tx[K, V]) Commit() error {
+	if err := tx.check(true); err != nil {
+		return err
+	}
+
+	return tx.commitIfOpen()
+}
+
+// Close discards any pending change and closes the transaction.
+func (tx *Tx[K, V]) Close() error {
+	if err := tx.check(false); err != nil {
+		return err
+	}
+
+	tx.release()
+	return nil
+}
+
+func (tx *Tx[K, V]) commitIfOpen() error {
+	if tx.done {
+		// nil unless rolled back by the context
+		return tx.err
+	}
+
+	if err := tx.checkContext(); err != nil {
+		return err
+	}
+	defer tx.release()
+
+	return tx.s.commit(tx)
+}
+
+// commit stores the changes of a transaction as a new version, in order,
+// unless a key it read was changed after it started.
+func (s *Store[K, V]) commit(tx *Tx[K, V]) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return behold.ErrClosed
+	}
+
+	for key := range tx.reads {
+		if e, ok := s.entries[key]; ok && e.changedSince(tx.version) {
+			return behold.NewConflictError(key)
+		}
+	}
+
+	version := s.version + 1
+	for _, key := range tx.order {
+		c := tx.changes[key]
+		s.addRecord(key, record[V]{
+			version: version,
+			value:   c.value,
+			deleted: c.deleted,
+		})
+	}
+
+	s.version = version
+	return nil
+}
+
+// addRecord appends a new version of a key. s.mu must be held for writing.
+func (s *Store[K, V]) addRecord(key K, r record[V]) {
+	e, ok := s.entries[key]
+	if !r.deleted {
+		s.index(key, r.value)
+	}
+
+	switch {
+	case ok:
+		e.history = append(e.history, r)
+	case r.deleted:
+		// created and deleted within the same transaction
+		return
+	default:
+		s.seq++
+		e = &entry[K, V]{key: key, seq: s.seq, history: []record[V]{r}}
+		s.entries[key] = e
+		s.order.Insert(e)
+	}
+
+	if !e.clean() {
+		s.garbage[e] = struct{}{}
+	}
+}
+
+// release closes the transaction, discarding pending changes
+// and unpinning its version.
+func (tx *Tx[K, V]) release() {
+	if tx.done {
+		return
+	}
+
+	tx.done = true
+	tx.changes = nil
+	tx.order = nil
+	tx.reads = nil
+	tx.saves = nil
+	tx.undo = nil
+
+	if tx.watch != nil {
+		tx.watch.stop()
+		runtime.SetFinalizer(tx, nil)
+	}
+
+	if tx.unlock != nil {
+		defer tx.unlock()
+	}
+
+	s := tx.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unpin(tx.version)
+}
+
+// check verifies the transaction can be used, and if it's
+// allowed to make changes when requested.
+func (tx *Tx[K, V]) check(write bool) error {
+	switch {
+	case tx == nil:
+		return behold.ErrNilReceiver
+	case tx.done && tx.err != nil:
+		return tx.err
+	case tx.done:
+		return behold.ErrClosed
+	case write && !tx.writable:
+		return behold.ErrReadOnlyTx
+	default:
+		return tx.checkContext()
+	}
+}
+
+// ctxCheckInterval is how many entries scans visit between
+// checks of the context.
+const ctxCheckInterval = 256
+
+// checkEvery checks the context once every ctxCheckInterval entries.
+func (tx *Tx[K, V]) checkEvery(i int) error {
+	if i > 0 && i%ctxCheckInterval == 0 {
+		return tx.checkContext()
+	}
+	return nil
+}
+
+// checkContext returns the error of the transaction's context if it
+// has ended, rolling back read-write transactions. s.mu must not be held.
+func (tx *Tx[K, V]) checkContext() error {
+	err := tx.ctx.Err()
+	if err != nil && tx.writable {
+		tx.err = err
+		tx.release()
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package memstore
+
+import (
+	"github.com/amery/behold"
+)
+
+// interface assertions
+var _ behold.VersionedTx[string, any] = (*Tx[string, any])(nil)
+
+// GetWithVersion returns the value associated to a key, and the version
+// of the Update that last committed a change to it. Changes pending in
+// the transaction are returned with the version of the last committed one.
+// If the key doesn't exist it fails with a behold.KeyError wrapping
+// behold.ErrNotFound.
+func (tx *Tx[K, V]) GetWithVersion(key K) (V, uint64, error) {
+	var zero V
+
+	if err := tx.check(false); err != nil {
+		return zero, 0, err
+	}
+
+	value, ok, err := tx.get(key)
+	switch {
+	case err != nil:
+		return zero, 0, err
+	case !ok:
+		return zero, 0, behold.NewNotFoundError(key)
+	}
+
+	version, err := tx.committedVersion(key)
+	if err != nil {
+		return zero, 0, err
+	}
+	return value, version, nil
+}
+
+// SetIfVersion associates a value with a key if the last committed
+// change to the key, as seen by the transaction, was at the given
+// version, or if the key didn't exist when the version is 0.
+// Otherwise it fails with a behold.KeyError wrapping behold.ErrConflict.
+func (tx *Tx[K, V]) SetIfVersion(key K, value V, version uint64) error {
+	if err := tx.check(true); err != nil {
+		return err
+	}
+
+	current, err := tx.committedVersion(key)
+	switch {
+	case err != nil:
+		return err
+	case current != version:
+		return behold.NewConflictError(key)
+	}
+
+	tx.setChange(key, change[V]{value: value})
+	return nil
+}
+
+// committedVersion returns the version of the last committed change to
+// the key as seen by the transaction, or 0 if it didn't exist.
+func (tx *Tx[K, V]) committedVersion(key K) (uint64, error) {
+	s := tx.s
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return 0, behold.ErrClosed
+	}
+
+	tx.markRead(key)
+	if e, ok := s.entries[key]; ok {
+		return e.versionAt(tx.version), nil
+	}
+	return 0, nil
+}
+
+// at returns the value of an entry as seen at the version of the
+// transaction, recording it was read. s.mu must be held.
+func (tx *Tx[K, V]) at(e *entry[K, V]) (V, bool) {
+	tx.markRead(e.key)
+	return e.at(tx.version)
+}
+
+// markRead records a key was read from the store, so committing fails
+// if another transaction changes it meanwhile.
+func (tx *Tx[K, V]) markRead(key K) {
+	if tx.reads != nil {
+		tx.reads[key] = struct{}{}
+	}
+}
@@ -0,0 +1,184 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+)
+
+// updateDuring runs an Update that calls before, waits for another
+// Update calling during to commit, and then calls after.
+func updateDuring(t *testing.T, s *Store[string, int],
+	before, during, after func(tx behold.Tx[string, int]) error) error {
+	t.Helper()
+
+	return s.Update(context.Background(), func(tx behold.Tx[string, int]) error {
+		if err := before(tx); err != nil {
+			return err
+		}
+
+		done := make(chan error)
+		go func() {
+			done <- s.Update(context.Background(), during)
+		}()
+		require.NoError(t, <-done)
+
+		return after(tx)
+	})
+}
+
+func TestConflict(t *testing.T) {
+	s := newTestStore(t)
+
+	setOne := func(v int) func(behold.Tx[string, int]) error {
+		return func(tx behold.Tx[string, int]) error { return tx.Set(keyOne, v) }
+	}
+
+	// read-modify-write
+	err := updateDuring(t, s,
+		func(tx behold.Tx[string, int]) error {
+			_, err := tx.Get(keyOne)
+			return err
+		},
+		setOne(10),
+		setOne(20))
+
+	var ke *behold.KeyError[string]
+	require.ErrorIs(t, err, behold.ErrConflict)
+	require.True(t, errors.As(err, &ke))
+	assert.Equal(t, keyOne, ke.Key)
+	assert.Equal(t, uint64(2), s.Version())
+
+	// reading other keys
+	err = updateDuring(t, s,
+		func(tx behold.Tx[string, int]) error {
+			_, err := tx.Get(keyTwo)
+			return err
+		},
+		setOne(30),
+		setOne(40))
+	require.NoError(t, err)
+
+	// scans read every visited key
+	err = updateDuring(t, s,
+		func(tx behold.Tx[string, int]) error {
+			return tx.ForEach(func(string, int) bool { return true })
+		},
+		setOne(50),
+		setOne(60))
+	assert.ErrorIs(t, err, behold.ErrConflict)
+
+	// blind writes
+	err = updateDuring(t, s,
+		func(behold.Tx[string, int]) error { return nil },
+		setOne(70),
+		setOne(80))
+	require.NoError(t, err)
+
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		v, err := tx.Get(keyOne)
+		assert.Equal(t, 80, v)
+		return err
+	}))
+}
+
+func TestSetIfVersion(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Update(ctx, func(tx behold.Tx[string, int]) error {
+		return tx.Set(keyTwo, 22)
+	}))
+
+	require.NoError(t, s.Update(ctx, func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.VersionedTx[string, int])
+
+		v, version, err := tx.GetWithVersion(keyOne)
+		require.NoError(t, err)
+		assert.Equal(t, 1, v)
+		assert.Equal(t, uint64(1), version)
+
+		_, version, err = tx.GetWithVersion(keyTwo)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), version)
+
+		_, _, err = tx.GetWithVersion("four")
+		assert.ErrorIs(t, err, behold.ErrNotFound)
+
+		assert.ErrorIs(t, tx.SetIfVersion(keyOne, 10, 2), behold.ErrConflict)
+		assert.NoError(t, tx.SetIfVersion(keyOne, 10, 1))
+		assert.ErrorIs(t, tx.SetIfVersion(keyTwo, 20, 0), behold.ErrConflict)
+		assert.NoError(t, tx.SetIfVersion("four", 4, 0))
+
+		// pending changes keep the committed version
+		v, version, err = tx.GetWithVersion(keyOne)
+		require.NoError(t, err)
+		assert.Equal(t, 10, v)
+		assert.Equal(t, uint64(1), version)
+		return nil
+	}))
+
+	require.NoError(t, s.View(ctx, func(btx behold.Tx[string, int]) error {
+		tx := btx.(behold.VersionedTx[string, int])
+
+		_, version, err := tx.GetWithVersion("four")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), version)
+
+		assert.ErrorIs(t, tx.SetIfVersion(keyOne, 1, 3), behold.ErrReadOnlyTx)
+		return nil
+	}))
+
+	// SetIfVersion conflicts with changes committed after it
+	err := updateDuring(t, s,
+		func(tx behold.Tx[string, int]) error {
+			return tx.(behold.VersionedTx[string, int]).SetIfVersion(keyThree, 33, 1)
+		},
+		func(tx behold.Tx[string, int]) error { return tx.Set(keyThree, 30) },
+		func(behold.Tx[string, int]) error { return nil })
+	assert.ErrorIs(t, err, behold.ErrConflict)
+}
+
+func TestUpdateWithRetry(t *testing.T) {
+	const writers, increments = 8, 20
+
+	s := newTestStore(t)
+	opts := behold.RetryOptions{
+		MaxAttempts: writers * increments,
+		Backoff:     behold.ExponentialBackoff(time.Microsecond, time.Millisecond),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < increments; j++ {
+				_, err := behold.UpdateWithRetry[string, int](context.Background(), s, opts,
+					func(tx behold.Tx[string, int]) error {
+						v, err := tx.Get(keyOne)
+						if err != nil {
+							return err
+						}
+						return tx.Set(keyOne, v+1)
+					})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.NoError(t, s.View(context.Background(), func(tx behold.Tx[string, int]) error {
+		v, err := tx.Get(keyOne)
+		assert.Equal(t, 1+writers*increments, v)
+		return err
+	}))
+}
@@ -0,0 +1,12 @@
+// Package qlang parses a small expression language into behold Query
+// trees, comparing the fields registered in a behold.Schema.
+//
+// Expressions compare fields against literals, combined with && and ||
+// and grouped using parentheses:
+//
+//	age >= 18 && (name == "John" || name == "Alice")
+//
+// The comparison operators are ==, !=, >, >=, < and <=. Literals are
+// numbers, like 42, -1.5 or 0x1f, and strings quoted like in Go, using
+// double quotes or backquotes. && binds tighter than ||.
+package qlang
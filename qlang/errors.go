@@ -0,0 +1,49 @@
+package qlang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amery/behold"
+)
+
+// SyntaxError is an error parsing an expression, describing
+// where in the expression it happened.
+type SyntaxError struct {
+	// Offset is the position of the error in the expression, in bytes.
+	Offset int
+	// Line is the line of the error, starting at 1.
+	Line int
+	// Column is the column of the error in its line,
+	// starting at 1 and counted in runes.
+	Column int
+	// Msg describes the error.
+	Msg string
+	// Err is the cause of the error. It's behold.ErrInvalid unless
+	// a more specific one is known.
+	Err error
+}
+
+func newError(expr string, offset int, format string, args ...any) *SyntaxError {
+	line := 1 + strings.Count(expr[:offset], "\n")
+	lineStart := strings.LastIndexByte(expr[:offset], '\n') + 1
+
+	return &SyntaxError{
+		Offset: offset,
+		Line:   line,
+		Column: 1 + len([]rune(expr[lineStart:offset])),
+		Msg:    fmt.Sprintf(format, args...),
+		Err:    behold.ErrInvalid,
+	}
+}
+
+// Error returns the description of the error, prefixed by
+// its line and column.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Unwrap returns the cause of the error.
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
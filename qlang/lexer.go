@@ -0,0 +1,170 @@
+package qlang
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind identifies the kind of a token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenCompare
+	tokenAnd
+	tokenOr
+	tokenLParen
+	tokenRParen
+)
+
+var tokenNames = map[tokenKind]string{
+	tokenEOF:     "end of expression",
+	tokenIdent:   "field name",
+	tokenNumber:  "number",
+	tokenString:  "string",
+	tokenCompare: "comparison operator",
+	tokenAnd:     "&&",
+	tokenOr:      "||",
+	tokenLParen:  "(",
+	tokenRParen:  ")",
+}
+
+func (k tokenKind) String() string {
+	return tokenNames[k]
+}
+
+// token is a lexical element of an expression.
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+}
+
+// String describes the token for error messages.
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return t.kind.String()
+	default:
+		return strconv.Quote(t.text)
+	}
+}
+
+// lexer splits an expression into tokens.
+type lexer struct {
+	expr string
+	pos  int
+}
+
+// operators are the symbols recognised by the lexer,
+// longest first.
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"==", tokenCompare},
+	{"!=", tokenCompare},
+	{">=", tokenCompare},
+	{"<=", tokenCompare},
+	{"&&", tokenAnd},
+	{"||", tokenOr},
+	{">", tokenCompare},
+	{"<", tokenCompare},
+	{"(", tokenLParen},
+	{")", tokenRParen},
+}
+
+// next returns the following token.
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+
+	start := l.pos
+	if start == len(l.expr) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+
+	rest := l.expr[start:]
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op.text) {
+			l.pos += len(op.text)
+			return token{kind: op.kind, pos: start, text: op.text}, nil
+		}
+	}
+
+	switch c := rest[0]; {
+	case isIdentStart(c):
+		return l.scan(tokenIdent, isIdentPart), nil
+	case isDigit(c), c == '-' && len(rest) > 1 && isDigit(rest[1]):
+		l.pos++
+		return l.scan(tokenNumber, isNumberPart), nil
+	case c == '"', c == '`':
+		return l.scanString()
+	default:
+		r, _ := utf8.DecodeRuneInString(rest)
+		return token{}, newError(l.expr, start, "unexpected %q", r)
+	}
+}
+
+// scan returns a token of the given kind extending from the
+// current position while accepted.
+func (l *lexer) scan(kind tokenKind, accept func(byte) bool) token {
+	start := l.pos
+	if kind == tokenNumber {
+		// the sign or first digit was already consumed
+		start--
+	}
+
+	for l.pos < len(l.expr) && accept(l.expr[l.pos]) {
+		l.pos++
+	}
+	return token{kind: kind, pos: start, text: l.expr[start:l.pos]}
+}
+
+// scanString returns a quoted string token, keeping the quotes.
+func (l *lexer) scanString() (token, error) {
+	start := l.pos
+	s, err := strconv.QuotedPrefix(l.expr[start:])
+	if err != nil {
+		return token{}, newError(l.expr, start, "unterminated or malformed string")
+	}
+
+	l.pos += len(s)
+	return token{kind: tokenString, pos: start, text: s}, nil
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.expr) {
+		switch l.expr[l.pos] {
+		case ' ', '\t', '\n', '\r':
+			l.pos++
+		default:
+			return
+		}
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c) || c == '.'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNumberPart(c byte) bool {
+	switch {
+	case isDigit(c), isIdentStart(c):
+		// digits, hex digits, base prefixes, exponents
+		return true
+	default:
+		return c == '.' || c == '+' || c == '-'
+	}
+}
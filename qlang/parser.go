@@ -0,0 +1,227 @@
+package qlang
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/amery/behold"
+)
+
+// Parse parses an expression into a Query over the fields of the schema.
+// Comparisons are built by the schema's fields, combined using
+// behold.MatchAll and behold.MatchAny. Errors are reported as
+// *SyntaxError.
+func Parse[V any](schema *behold.Schema[V], expr string) (behold.Query[V], error) {
+	if schema == nil {
+		return nil, behold.ErrInvalid
+	}
+
+	p := &parser[V]{
+		schema: schema,
+		lex:    lexer{expr: expr},
+	}
+	return p.parse()
+}
+
+// MustParse is like Parse but panics if the expression can't be parsed.
+func MustParse[V any](schema *behold.Schema[V], expr string) behold.Query[V] {
+	q, err := Parse(schema, expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// parser is a recursive descent parser of expressions:
+//
+//	expr       = and { "||" and } .
+//	and        = operand { "&&" operand } .
+//	operand    = "(" expr ")" | comparison .
+//	comparison = field op literal .
+type parser[V any] struct {
+	schema *behold.Schema[V]
+	lex    lexer
+	tok    token
+}
+
+func (p *parser[V]) parse() (behold.Query[V], error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenEOF {
+		return nil, p.unexpected("&&, || or end of expression")
+	}
+	return q, nil
+}
+
+// advance moves to the next token.
+func (p *parser[V]) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser[V]) errorAt(pos int, format string, args ...any) *SyntaxError {
+	return newError(p.lex.expr, pos, format, args...)
+}
+
+// unexpected reports the current token isn't the expected one.
+func (p *parser[V]) unexpected(expected string) error {
+	return p.errorAt(p.tok.pos, "expected %s, got %s", expected, p.tok)
+}
+
+func (p *parser[V]) parseOr() (behold.Query[V], error) {
+	return p.parseList(tokenOr, p.parseAnd, behold.MatchAny[V])
+}
+
+func (p *parser[V]) parseAnd() (behold.Query[V], error) {
+	return p.parseList(tokenAnd, p.parseOperand, behold.MatchAll[V])
+}
+
+// parseList parses operands separated by the given token, joining them
+// if there is more than one.
+func (p *parser[V]) parseList(sep tokenKind, operand func() (behold.Query[V], error),
+	join func(...behold.Query[V]) behold.Query[V]) (behold.Query[V], error) {
+	q, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	queries := []behold.Query[V]{q}
+	for p.tok.kind == sep {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+
+		q, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+
+	if len(queries) == 1 {
+		return queries[0], nil
+	}
+	return join(queries...), nil
+}
+
+func (p *parser[V]) parseOperand() (behold.Query[V], error) {
+	switch p.tok.kind {
+	case tokenLParen:
+		return p.parseGroup()
+	case tokenIdent:
+		return p.parseComparison()
+	default:
+		return nil, p.unexpected("field name or (")
+	}
+}
+
+func (p *parser[V]) parseGroup() (behold.Query[V], error) {
+	open := p.tok.pos
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	q, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenRParen {
+		if p.tok.kind == tokenEOF {
+			return nil, p.errorAt(open, "unclosed (")
+		}
+		return nil, p.unexpected(")")
+	}
+
+	return q, p.advance()
+}
+
+func (p *parser[V]) parseComparison() (behold.Query[V], error) {
+	name := p.tok
+	f, ok := p.schema.Field(name.text)
+	if !ok {
+		return nil, p.errorAt(name.pos, "unknown field %q", name.text)
+	}
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokenCompare {
+		return nil, p.unexpected("comparison operator")
+	}
+	op, _ := behold.ParseCompareOp(p.tok.text)
+
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	lit := p.tok
+	value, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := f.Query(op, value)
+	if err != nil {
+		msg := strings.TrimSuffix(err.Error(), ": "+behold.ErrInvalid.Error())
+		e := p.errorAt(lit.pos, "%s", msg)
+		e.Err = err
+		return nil, e
+	}
+
+	return q, p.advance()
+}
+
+// literal returns the value of the current token, which must be
+// a number or a string.
+func (p *parser[V]) literal() (any, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenString:
+		s, err := strconv.Unquote(tok.text)
+		if err != nil {
+			return nil, p.errorAt(tok.pos, "malformed string")
+		}
+		return s, nil
+	case tokenNumber:
+		return p.number(tok)
+	default:
+		return nil, p.unexpected("number or string")
+	}
+}
+
+// number parses a number literal as int64, uint64 or float64,
+// whichever fits first.
+func (p *parser[V]) number(tok token) (any, error) {
+	i, errInt := strconv.ParseInt(tok.text, 0, 64)
+	if errInt == nil {
+		return i, nil
+	}
+
+	u, errUint := strconv.ParseUint(tok.text, 0, 64)
+	switch {
+	case errUint == nil:
+		return u, nil
+	case errors.Is(errInt, strconv.ErrRange), errors.Is(errUint, strconv.ErrRange):
+		return nil, p.errorAt(tok.pos, "number %s out of range", tok.text)
+	}
+
+	if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+		return f, nil
+	}
+
+	return nil, p.errorAt(tok.pos, "malformed number %s", tok.text)
+}
@@ -0,0 +1,144 @@
+package qlang
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/amery/behold"
+	"github.com/amery/behold/memstore"
+)
+
+type person struct {
+	Name   string
+	Age    int
+	Height float64
+}
+
+func personName(p person) string    { return p.Name }
+func personAge(p person) int        { return p.Age }
+func personHeight(p person) float64 { return p.Height }
+
+// byAge is shared by the schema and indexes
+var byAge = behold.NewAccessor(personAge)
+
+func newSchema(t *testing.T) *behold.Schema[person] {
+	t.Helper()
+
+	s := behold.NewSchema[person]()
+	require.NoError(t, behold.AddField(s, "name", personName))
+	require.NoError(t, behold.AddAccessor(s, "age", byAge))
+	require.NoError(t, behold.AddField(s, "height", personHeight))
+	return s
+}
+
+var (
+	john  = person{"John", 30, 1.80}
+	alice = person{"Alice", 17, 1.65}
+	bob   = person{"Bob", 45, 1.75}
+)
+
+func TestParse(t *testing.T) {
+	s := newSchema(t)
+
+	for _, tc := range []struct {
+		expr    string
+		matches []person
+		desc    string
+	}{
+		{`age >= 18`, []person{john, bob},
+			"qlang.personAge >= 18"},
+		{`age >= 18 && (name == "John" || name == "Alice")`, []person{john},
+			"(qlang.personAge >= 18 AND (qlang.personName == John OR qlang.personName == Alice))"},
+		{`name == "Alice" || age > 40 && height < 1.8`, []person{alice, bob},
+			"(qlang.personName == Alice OR (qlang.personAge > 40 AND qlang.personHeight < 1.8))"},
+		{"name != `Bob` && height <= 0x2", []person{john, alice},
+			"(qlang.personName != Bob AND qlang.personHeight <= 2)"},
+		{` ( ( age < 20 ) ) `, []person{alice},
+			"qlang.personAge < 20"},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			q, err := Parse(s, tc.expr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.desc, behold.DescribeQuery(q))
+
+			var got []person
+			for _, p := range []person{john, alice, bob} {
+				if q.Match(p) {
+					got = append(got, p)
+				}
+			}
+			assert.Equal(t, tc.matches, got)
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	s := newSchema(t)
+
+	for _, tc := range []struct {
+		expr   string
+		line   int
+		column int
+		msg    string
+	}{
+		{``, 1, 1, "expected field name or (, got end of expression"},
+		{`agee == 1`, 1, 1, `unknown field "agee"`},
+		{`age = 1`, 1, 5, "unexpected '='"},
+		{`age 1`, 1, 5, `expected comparison operator, got "1"`},
+		{`age == name`, 1, 8, `expected number or string, got "name"`},
+		{`age == "x"`, 1, 8, `field "age": can't compare int against string "x"`},
+		{`age == 1.5`, 1, 8, `field "age": can't compare int against float64 1.5`},
+		{`age == 99999999999999999999`, 1, 8, "number 99999999999999999999 out of range"},
+		{`age == 1e`, 1, 8, "malformed number 1e"},
+		{`name == "abc`, 1, 9, "unterminated or malformed string"},
+		{`(age == 1`, 1, 1, "unclosed ("},
+		{`(age == 1 name`, 1, 11, `expected ), got "name"`},
+		{`age == 1)`, 1, 9, `expected &&, || or end of expression, got ")"`},
+		{"age == 1 &&\n  ñame == 2", 2, 3, "unexpected 'ñ'"},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := Parse(s, tc.expr)
+
+			var se *SyntaxError
+			require.ErrorAs(t, err, &se)
+			assert.ErrorIs(t, err, behold.ErrInvalid)
+			assert.Equal(t, tc.line, se.Line)
+			assert.Equal(t, tc.column, se.Column)
+			assert.Equal(t, tc.msg, se.Msg)
+		})
+	}
+
+	assert.Panics(t, func() { MustParse(s, `age`) })
+}
+
+func TestParseIndexed(t *testing.T) {
+	st := memstore.New[string, person]()
+	defer st.Close()
+
+	require.NoError(t, memstore.AddIndex(st, "age", byAge))
+
+	ctx := context.Background()
+	require.NoError(t, st.Update(ctx, func(tx behold.Tx[string, person]) error {
+		for _, p := range []person{john, alice, bob} {
+			if err := tx.Set(p.Name, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	q := MustParse(newSchema(t), `age >= 18 && name != "Bob"`)
+	require.NoError(t, st.View(ctx, func(tx behold.Tx[string, person]) error {
+		plan, err := tx.(*memstore.Tx[string, person]).Plan(q)
+		require.NoError(t, err)
+		assert.True(t, plan.Indexed(), plan.Explain())
+
+		n, err := behold.Count(tx, q)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		return nil
+	}))
+}
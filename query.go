@@ -1,5 +1,13 @@
 package behold
 
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+)
+
 // Query is a generic interface for filtering and combining predicates of type T.
 // It allows logical AND and OR operations between query conditions, and matching against a value.
 type Query[T any] interface {
@@ -38,6 +46,14 @@ func (fn QueryFunc[T]) Match(value T) bool {
 	return fn(value)
 }
 
+// String returns the name of the query function.
+func (fn QueryFunc[T]) String() string {
+	if fn == nil {
+		return "true"
+	}
+	return funcName(fn)
+}
+
 // MatchAny returns a query that matches if any of the provided queries match.
 // If no queries are provided, the result will match nothing (return false).
 // Nil queries in the provided list are ignored during matching.
@@ -45,6 +61,27 @@ func MatchAny[T any](queries ...Query[T]) Query[T] {
 	return ors[T](queries)
 }
 
+// MatchNone returns a query that matches if none of the provided queries match.
+// If no queries are provided, the result will match everything (return true).
+// Nil queries in the provided list are ignored during matching.
+func MatchNone[T any](queries ...Query[T]) Query[T] {
+	return &notQuery[T]{query: ors[T](queries)}
+}
+
+// Not returns a query that matches if the provided query doesn't.
+// A nil query matches everything, so its negation matches nothing.
+func Not[T any](query Query[T]) Query[T] {
+	return &notQuery[T]{query: query}
+}
+
+// Xor returns a query that matches if an odd number of the provided queries
+// match, which for two queries means exactly one of them.
+// If no queries are provided, the result will match nothing (return false).
+// Nil queries in the provided list are ignored during matching.
+func Xor[T any](queries ...Query[T]) Query[T] {
+	return xors[T](queries)
+}
+
 // MatchAll returns a query that matches if all of the provided queries match.
 // If no queries are provided, the result will match everything (return true).
 // Nil queries in the provided list are ignored during matching.
@@ -52,6 +89,96 @@ func MatchAll[T any](queries ...Query[T]) Query[T] {
 	return ands[T](queries)
 }
 
+// LogicalOp identifies how a LogicalQuery combines its operands.
+type LogicalOp int
+
+const (
+	// OpAnd matches if all operands match.
+	OpAnd LogicalOp = iota + 1
+	// OpOr matches if any operand matches.
+	OpOr
+	// OpNot matches if its only operand doesn't.
+	OpNot
+	// OpXor matches if an odd number of operands match.
+	OpXor
+)
+
+var logicalOpNames = map[LogicalOp]string{
+	OpAnd: "AND",
+	OpOr:  "OR",
+	OpNot: "NOT",
+	OpXor: "XOR",
+}
+
+// String returns the operator's name.
+func (op LogicalOp) String() string {
+	if s, ok := logicalOpNames[op]; ok {
+		return s
+	}
+	return fmt.Sprintf("LogicalOp(%d)", int(op))
+}
+
+// LogicalQuery is a Query combining other queries, as created by
+// MatchAll, MatchAny, MatchNone, Not, Xor and the And and Or methods.
+// It allows stores to plan how to resolve the combined queries.
+type LogicalQuery[T any] interface {
+	Query[T]
+
+	// Operator returns how the operands are combined.
+	Operator() LogicalOp
+
+	// Operands returns a copy of the combined queries.
+	Operands() []Query[T]
+}
+
+// DescribeQuery returns a human-readable description of a query.
+// Queries implementing fmt.Stringer describe themselves, others
+// are described by their type.
+func DescribeQuery[T any](q Query[T]) string {
+	switch v := q.(type) {
+	case nil:
+		return "nil"
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%T", q)
+	}
+}
+
+// describeAll describes the non-nil queries joined by the operator,
+// or gives the empty description when there are none.
+func describeAll[T any](queries []Query[T], op LogicalOp, empty string) string {
+	parts := make([]string, 0, len(queries))
+	for _, q := range queries {
+		if q != nil {
+			parts = append(parts, DescribeQuery(q))
+		}
+	}
+
+	switch len(parts) {
+	case 0:
+		return empty
+	case 1:
+		return parts[0]
+	default:
+		return "(" + strings.Join(parts, " "+op.String()+" ") + ")"
+	}
+}
+
+// funcName returns the short name of a function, including its package.
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "func"
+	}
+
+	name := f.Name()
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
 // ands is a slice of queries that implements the Query interface with AND logic.
 type ands[T any] []Query[T]
 
@@ -76,6 +203,15 @@ func (c ands[T]) Or(others ...Query[T]) Query[T] {
 	return ors[T](qJoin(c, others))
 }
 
+// Operator returns OpAnd.
+func (ands[T]) Operator() LogicalOp { return OpAnd }
+
+// Operands returns a copy of the combined queries.
+func (c ands[T]) Operands() []Query[T] { return slices.Clone(c) }
+
+// String describes the combined queries.
+func (c ands[T]) String() string { return describeAll(c, OpAnd, "true") }
+
 // ors is a slice of queries that implements the Query interface with OR logic.
 type ors[T any] []Query[T]
 
@@ -100,6 +236,84 @@ func (c ors[T]) Or(others ...Query[T]) Query[T] {
 	return append(c, others...)
 }
 
+// Operator returns OpOr.
+func (ors[T]) Operator() LogicalOp { return OpOr }
+
+// Operands returns a copy of the combined queries.
+func (c ors[T]) Operands() []Query[T] { return slices.Clone(c) }
+
+// String describes the combined queries.
+func (c ors[T]) String() string { return describeAll(c, OpOr, "false") }
+
+// notQuery is a query that implements the Query interface with NOT logic.
+type notQuery[T any] struct {
+	query Query[T]
+}
+
+// Match returns true if the negated query doesn't match the provided value.
+// A nil query matches everything, so its negation matches nothing.
+func (q *notQuery[T]) Match(value T) bool {
+	return q.query != nil && !q.query.Match(value)
+}
+
+// And combines this NOT query with others using logical AND.
+func (q *notQuery[T]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](q, others))
+}
+
+// Or combines this NOT query with others using logical OR.
+func (q *notQuery[T]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](q, others))
+}
+
+// Operator returns OpNot.
+func (*notQuery[T]) Operator() LogicalOp { return OpNot }
+
+// Operands returns the negated query.
+func (q *notQuery[T]) Operands() []Query[T] { return []Query[T]{q.query} }
+
+// String describes the negated query.
+func (q *notQuery[T]) String() string {
+	if q.query == nil {
+		return "false"
+	}
+	return "NOT " + DescribeQuery(q.query)
+}
+
+// xors is a slice of queries that implements the Query interface with XOR logic.
+type xors[T any] []Query[T]
+
+// Match returns true if an odd number of non-nil queries in the slice match
+// the provided value. An empty slice matches nothing (returns false).
+func (c xors[T]) Match(value T) bool {
+	var odd bool
+	for _, q := range c {
+		if q != nil && q.Match(value) {
+			odd = !odd
+		}
+	}
+	return odd
+}
+
+// And combines this XOR query with others using logical AND.
+func (c xors[T]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](c, others))
+}
+
+// Or combines this XOR query with others using logical OR.
+func (c xors[T]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](c, others))
+}
+
+// Operator returns OpXor.
+func (xors[T]) Operator() LogicalOp { return OpXor }
+
+// Operands returns a copy of the combined queries.
+func (c xors[T]) Operands() []Query[T] { return slices.Clone(c) }
+
+// String describes the combined queries.
+func (c xors[T]) String() string { return describeAll(c, OpXor, "false") }
+
 // qJoin combines a query with a slice of other queries into a single slice.
 // If the first query is nil, it simply returns the others slice.
 func qJoin[T any](fn Query[T], others []Query[T]) []Query[T] {
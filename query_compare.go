@@ -1,11 +1,18 @@
 package behold
 
-import "darvaza.org/core"
+import (
+	"fmt"
+
+	"darvaza.org/core"
+)
 
 // ComposeQuery creates a new Query by applying an accessor function to transform input values
 // before matching against an existing query. It allows composing queries on different types
 // by first extracting a specific field or transforming the input. Panics if the accessor
 // function or the base query is nil.
+//
+// As functions can't be compared, the result is never served by an index. Use the
+// Accessor the index was created with to build queries indexes can serve.
 func ComposeQuery[T any, V any](fn func(T) V, query Query[V]) Query[T] {
 	if fn == nil {
 		panic(core.NewPanicError(1, "nil accessor function"))
@@ -15,17 +22,179 @@ func ComposeQuery[T any, V any](fn func(T) V, query Query[V]) Query[T] {
 		panic(core.NewPanicError(1, "nil value query"))
 	}
 
-	return QueryFunc[T](func(x T) bool {
-		return query.Match(fn(x))
-	})
+	return &composeQuery[T, V]{fn: fn, query: query}
+}
+
+// ComposedQuery is a Query matching the result of an accessor function
+// against another Query, as created by ComposeQuery. It allows stores
+// to recognise queries over indexed fields.
+type ComposedQuery[T any, V any] interface {
+	Query[T]
+
+	// Accessor returns the function applied to the input values.
+	Accessor() func(T) V
+
+	// Base returns the query the accessor's result is matched against.
+	Base() Query[V]
+}
+
+// composeQuery is the ComposedQuery created by ComposeQuery,
+// or by an Accessor, which is then recorded.
+type composeQuery[T any, V any] struct {
+	fn    func(T) V
+	query Query[V]
+	acc   *Accessor[T, V]
+}
+
+// composer is a query built around a composeQuery.
+type composer[T any, V any] interface {
+	composed() *composeQuery[T, V]
+}
+
+func (q *composeQuery[T, V]) composed() *composeQuery[T, V] { return q }
+
+// sameAs tells if the other query was built by the same Accessor
+// over an identical base query.
+func (q *composeQuery[T, V]) sameAs(other Query[T]) bool {
+	o, ok := other.(composer[T, V])
+	if !ok || q.acc == nil {
+		return false
+	}
+
+	oq := o.composed()
+	return oq.acc == q.acc && identical(q.query, oq.query)
+}
+
+// And combines this query with others using logical AND.
+func (q *composeQuery[T, V]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](q, others))
+}
+
+// Or combines this query with others using logical OR.
+func (q *composeQuery[T, V]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](q, others))
+}
+
+// Match tests if the accessor's result for the value matches the base query.
+func (q *composeQuery[T, V]) Match(x T) bool {
+	return q.query.Match(q.fn(x))
+}
+
+// Accessor returns the function applied to the input values.
+func (q *composeQuery[T, V]) Accessor() func(T) V { return q.fn }
+
+// Base returns the query the accessor's result is matched against.
+func (q *composeQuery[T, V]) Base() Query[V] { return q.query }
+
+// String describes the query as the accessor's name followed by
+// the description of the base query.
+func (q *composeQuery[T, V]) String() string {
+	return funcName(q.fn) + " " + DescribeQuery(q.query)
+}
+
+// cost estimates the cost of calling the accessor and matching
+// the base query.
+func (q *composeQuery[T, V]) cost() int {
+	return costCall + queryCost(q.query)
+}
+
+// CompareOp identifies the comparison performed by a ComparisonQuery.
+type CompareOp int
+
+const (
+	// OpEq matches values equal to the operand.
+	OpEq CompareOp = iota + 1
+	// OpNotEq matches values not equal to the operand.
+	OpNotEq
+	// OpGt matches values greater than the operand.
+	OpGt
+	// OpGtEq matches values greater than or equal to the operand.
+	OpGtEq
+	// OpLt matches values less than the operand.
+	OpLt
+	// OpLtEq matches values less than or equal to the operand.
+	OpLtEq
+)
+
+var compareOpNames = map[CompareOp]string{
+	OpEq:    "==",
+	OpNotEq: "!=",
+	OpGt:    ">",
+	OpGtEq:  ">=",
+	OpLt:    "<",
+	OpLtEq:  "<=",
+}
+
+// String returns the operator's symbol.
+func (op CompareOp) String() string {
+	if s, ok := compareOpNames[op]; ok {
+		return s
+	}
+	return fmt.Sprintf("CompareOp(%d)", int(op))
+}
+
+// ParseCompareOp returns the operator with the given symbol.
+func ParseCompareOp(s string) (CompareOp, bool) {
+	for op, name := range compareOpNames {
+		if name == s {
+			return op, true
+		}
+	}
+	return 0, false
+}
+
+// ComparisonQuery is a Query comparing values against a fixed operand
+// using the natural order of the type, as created by EqQuery, GtQuery
+// and their siblings. It allows stores to serve them using indexes.
+type ComparisonQuery[T any] interface {
+	Query[T]
+
+	// Op returns the comparison performed.
+	Op() CompareOp
+
+	// Operand returns the value compared against.
+	Operand() T
+}
+
+// compareQuery is a ComparisonQuery.
+type compareQuery[T any] struct {
+	op      CompareOp
+	operand T
+	match   func(v, operand T) bool
+}
+
+func newCompareQuery[T any](op CompareOp, operand T, match func(v, operand T) bool) Query[T] {
+	return &compareQuery[T]{op: op, operand: operand, match: match}
+}
+
+// And combines this query with others using logical AND.
+func (q *compareQuery[T]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](q, others))
+}
+
+// Or combines this query with others using logical OR.
+func (q *compareQuery[T]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](q, others))
+}
+
+// Match tests the value against the operand.
+func (q *compareQuery[T]) Match(v T) bool { return q.match(v, q.operand) }
+
+// Op returns the comparison performed.
+func (q *compareQuery[T]) Op() CompareOp { return q.op }
+
+// Operand returns the value compared against.
+func (q *compareQuery[T]) Operand() T { return q.operand }
+
+// String describes the comparison, like "> 5".
+func (q *compareQuery[T]) String() string {
+	return fmt.Sprintf("%s %v", q.op, q.operand)
 }
 
 // EqQuery creates a Query that checks for equality with the given value.
 // It returns a function that returns true if the input is equal to the specified value.
 func EqQuery[T comparable](v T) Query[T] {
-	return QueryFunc[T](func(v0 T) bool {
-		return Eq(v0, v)
-	})
+	return newCompareQuery(OpEq, v, Eq[T])
 }
 
 // EqQueryFn creates a Query that checks for equality using a custom comparison function.
@@ -56,9 +225,7 @@ func EqQueryFn2[T any](v T, eq CondFunc[T]) Query[T] {
 // NotEqQuery creates a Query that checks for inequality with the given value.
 // It returns a function that returns true if the input is not equal to the specified value.
 func NotEqQuery[T comparable](v T) Query[T] {
-	return QueryFunc[T](func(v0 T) bool {
-		return NotEq(v0, v)
-	})
+	return newCompareQuery(OpNotEq, v, NotEq[T])
 }
 
 // NotEqQueryFn creates a Query that checks for inequality using a custom comparison function.
@@ -89,9 +256,7 @@ func NotEqQueryFn2[T any](v T, eq CondFunc[T]) Query[T] {
 // GtQuery creates a Query that checks if a value is strictly greater than the given value.
 // It returns a function that returns true if the input is greater than the specified value.
 func GtQuery[T core.Ordered](v T) Query[T] {
-	return QueryFunc[T](func(v0 T) bool {
-		return Gt(v0, v)
-	})
+	return newCompareQuery(OpGt, v, Gt[T])
 }
 
 // GtQueryFn creates a Query that checks if a value is strictly greater than the given value
@@ -110,9 +275,7 @@ func GtQueryFn[T core.Ordered](v T, cmp CompFunc[T]) Query[T] {
 // GtEqQuery creates a Query that checks if a value is greater than or equal to the given value.
 // It returns a function that returns true if the input is greater than or equal to the specified value.
 func GtEqQuery[T core.Ordered](v T) Query[T] {
-	return QueryFunc[T](func(v0 T) bool {
-		return GtEq(v0, v)
-	})
+	return newCompareQuery(OpGtEq, v, GtEq[T])
 }
 
 // GtEqQueryFn creates a Query that checks if a value is greater than or equal to the given value
@@ -144,9 +307,7 @@ func GtEqQueryFn2[T core.Ordered](v T, less CondFunc[T]) Query[T] {
 // LtQuery creates a Query that checks if a value is strictly less than the given value.
 // It returns a function that returns true if the input is less than the specified value.
 func LtQuery[T core.Ordered](v T) Query[T] {
-	return QueryFunc[T](func(v0 T) bool {
-		return Lt(v0, v)
-	})
+	return newCompareQuery(OpLt, v, Lt[T])
 }
 
 // LtQueryFn creates a Query that checks if a value is strictly less than the given value
@@ -178,9 +339,7 @@ func LtQueryFn2[T core.Ordered](v T, less CondFunc[T]) Query[T] {
 // LtEqQuery creates a Query that checks if a value is less than or equal to the given value.
 // It returns a function that returns true if the input is less than or equal to the specified value.
 func LtEqQuery[T core.Ordered](v T) Query[T] {
-	return QueryFunc[T](func(v0 T) bool {
-		return LtEq(v0, v)
-	})
+	return newCompareQuery(OpLtEq, v, LtEq[T])
 }
 
 // LtEqQueryFn creates a Query that checks if a value is less than or equal to the given value
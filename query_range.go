@@ -0,0 +1,202 @@
+package behold
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// RangeQuery is a Query matching values between two bounds, both
+// inclusive, as created by BetweenQuery and BetweenQueryFn. It allows
+// stores to serve it as a single range using indexes.
+type RangeQuery[T any] interface {
+	Query[T]
+
+	// Bounds returns the lowest and highest values matched.
+	Bounds() (lo, hi T)
+
+	// Comparison returns the function ordering the values,
+	// or nil if they follow the natural order of the type.
+	Comparison() CompFunc[T]
+}
+
+// SetQuery is a Query matching values in a set, or not in it if
+// negated, as created by InQuery, NotInQuery and their Fn variants.
+// It allows stores to serve it using indexes.
+type SetQuery[T any] interface {
+	Query[T]
+
+	// Values returns a copy of the values of the set.
+	Values() []T
+
+	// Negated tells if the query matches values not in the set.
+	Negated() bool
+
+	// Comparison returns the function comparing the values,
+	// or nil if they are compared for equality.
+	Comparison() CompFunc[T]
+}
+
+// BetweenQuery creates a Query that checks if a value is between lo and hi,
+// both inclusive. It matches nothing if lo is greater than hi.
+func BetweenQuery[T core.Ordered](lo, hi T) Query[T] {
+	return &betweenQuery[T]{lo: lo, hi: hi, cmp: cmp.Compare[T]}
+}
+
+// BetweenQueryFn creates a Query that checks if a value is between lo and hi,
+// both inclusive, according to the provided comparison function.
+// Panics if the comparison function is nil.
+func BetweenQueryFn[T any](lo, hi T, cmp CompFunc[T]) Query[T] {
+	if cmp == nil {
+		panic(newNilCompFuncErr())
+	}
+	return &betweenQuery[T]{lo: lo, hi: hi, cmp: cmp, custom: true}
+}
+
+// betweenQuery is a RangeQuery.
+type betweenQuery[T any] struct {
+	lo, hi T
+	cmp    CompFunc[T]
+	custom bool
+}
+
+// And combines this query with others using logical AND.
+func (q *betweenQuery[T]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](q, others))
+}
+
+// Or combines this query with others using logical OR.
+func (q *betweenQuery[T]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](q, others))
+}
+
+// Match tests if the value is within the bounds.
+func (q *betweenQuery[T]) Match(v T) bool {
+	return q.cmp(v, q.lo) >= 0 && q.cmp(v, q.hi) <= 0
+}
+
+// Bounds returns the lowest and highest values matched.
+func (q *betweenQuery[T]) Bounds() (lo, hi T) { return q.lo, q.hi }
+
+// Comparison returns the custom comparison function, if any.
+func (q *betweenQuery[T]) Comparison() CompFunc[T] {
+	if q.custom {
+		return q.cmp
+	}
+	return nil
+}
+
+// String describes the range, like "BETWEEN 1 AND 5".
+func (q *betweenQuery[T]) String() string {
+	return fmt.Sprintf("BETWEEN %v AND %v", q.lo, q.hi)
+}
+
+// InQuery creates a Query that checks if a value is one of the given values,
+// using a hash set.
+func InQuery[T comparable](values ...T) Query[T] {
+	return newHashSetQuery(values, false)
+}
+
+// NotInQuery creates a Query that checks if a value is none of the given
+// values, using a hash set.
+func NotInQuery[T comparable](values ...T) Query[T] {
+	return newHashSetQuery(values, true)
+}
+
+// InQueryFn creates a Query that checks if a value is equal to one of the
+// given values according to the provided comparison function, using a
+// sorted copy of the values. Panics if the comparison function is nil.
+func InQueryFn[T any](cmp CompFunc[T], values ...T) Query[T] {
+	return newSortedSetQuery(cmp, values, false)
+}
+
+// NotInQueryFn creates a Query that checks if a value is equal to none of
+// the given values according to the provided comparison function, using a
+// sorted copy of the values. Panics if the comparison function is nil.
+func NotInQueryFn[T any](cmp CompFunc[T], values ...T) Query[T] {
+	return newSortedSetQuery(cmp, values, true)
+}
+
+func newHashSetQuery[T comparable](values []T, negated bool) *setQuery[T] {
+	set := make(map[T]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+
+	return &setQuery[T]{
+		values:  slices.Clone(values),
+		negated: negated,
+		contains: func(v T) bool {
+			_, ok := set[v]
+			return ok
+		},
+	}
+}
+
+func newSortedSetQuery[T any](cmp CompFunc[T], values []T, negated bool) *setQuery[T] {
+	if cmp == nil {
+		panic(newNilCompFuncErr())
+	}
+
+	sorted := slices.Clone(values)
+	slices.SortFunc(sorted, cmp)
+
+	return &setQuery[T]{
+		values:  slices.Clone(values),
+		negated: negated,
+		cmp:     cmp,
+		contains: func(v T) bool {
+			_, ok := slices.BinarySearchFunc(sorted, v, cmp)
+			return ok
+		},
+	}
+}
+
+// setQuery is a SetQuery.
+type setQuery[T any] struct {
+	values   []T
+	negated  bool
+	cmp      CompFunc[T]
+	contains func(T) bool
+}
+
+// And combines this query with others using logical AND.
+func (q *setQuery[T]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](q, others))
+}
+
+// Or combines this query with others using logical OR.
+func (q *setQuery[T]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](q, others))
+}
+
+// Match tests if the value is in the set, or not if negated.
+func (q *setQuery[T]) Match(v T) bool {
+	return q.contains(v) != q.negated
+}
+
+// Values returns a copy of the values of the set.
+func (q *setQuery[T]) Values() []T { return slices.Clone(q.values) }
+
+// Negated tells if the query matches values not in the set.
+func (q *setQuery[T]) Negated() bool { return q.negated }
+
+// Comparison returns the custom comparison function, if any.
+func (q *setQuery[T]) Comparison() CompFunc[T] { return q.cmp }
+
+// String describes the set, like "IN (1, 2, 3)".
+func (q *setQuery[T]) String() string {
+	parts := make([]string, len(q.values))
+	for i, v := range q.values {
+		parts[i] = fmt.Sprint(v)
+	}
+
+	s := "IN (" + strings.Join(parts, ", ") + ")"
+	if q.negated {
+		s = "NOT " + s
+	}
+	return s
+}
@@ -0,0 +1,76 @@
+package behold
+
+import (
+	"cmp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetweenQuery(t *testing.T) {
+	for _, q := range []Query[int]{
+		BetweenQuery(2, 4),
+		BetweenQueryFn(2, 4, cmp.Compare[int]),
+	} {
+		for v, want := range map[int]bool{1: false, 2: true, 3: true, 4: true, 5: false} {
+			assert.Equal(t, want, q.Match(v), "%s: %v", DescribeQuery(q), v)
+		}
+		assert.Equal(t, "BETWEEN 2 AND 4", DescribeQuery(q))
+
+		rq, ok := q.(RangeQuery[int])
+		if assert.True(t, ok) {
+			lo, hi := rq.Bounds()
+			assert.Equal(t, []int{2, 4}, []int{lo, hi})
+		}
+	}
+
+	assert.Nil(t, BetweenQuery(1, 2).(RangeQuery[int]).Comparison())
+	assert.NotNil(t, BetweenQueryFn(1, 2, cmp.Compare[int]).(RangeQuery[int]).Comparison())
+	assert.False(t, BetweenQuery(4, 2).Match(3))
+
+	// reversed order
+	q := BetweenQueryFn(4, 2, Reverse(cmp.Compare[int]))
+	assert.True(t, q.Match(3))
+	assert.False(t, q.Match(5))
+
+	assert.Panics(t, func() { BetweenQueryFn[int](1, 2, nil) })
+}
+
+func TestSetQuery(t *testing.T) {
+	fold := func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}
+
+	for _, tc := range []struct {
+		q       Query[string]
+		negated bool
+		matches []string
+		desc    string
+	}{
+		{InQuery(nameJohn, nameAlice), false, []string{nameJohn, nameAlice}, "IN (John, Alice)"},
+		{NotInQuery(nameJohn, nameAlice), true, []string{nameBob, "john"}, "NOT IN (John, Alice)"},
+		{InQueryFn(fold, nameJohn, nameAlice), false,
+			[]string{nameJohn, nameAlice, "john"}, "IN (John, Alice)"},
+		{NotInQueryFn(fold, nameJohn, nameAlice), true, []string{nameBob}, "NOT IN (John, Alice)"},
+	} {
+		var got []string
+		for _, v := range []string{nameJohn, nameAlice, nameBob, "john"} {
+			if tc.q.Match(v) {
+				got = append(got, v)
+			}
+		}
+		assert.Equal(t, tc.matches, got, tc.desc)
+		assert.Equal(t, tc.desc, DescribeQuery(tc.q))
+
+		sq, ok := tc.q.(SetQuery[string])
+		if assert.True(t, ok) {
+			assert.Equal(t, tc.negated, sq.Negated())
+			assert.Equal(t, []string{nameJohn, nameAlice}, sq.Values())
+		}
+	}
+
+	assert.False(t, InQuery[int]().Match(1))
+	assert.True(t, NotInQuery[int]().Match(1))
+	assert.Panics(t, func() { InQueryFn[int](nil, 1) })
+}
@@ -0,0 +1,132 @@
+package behold
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"darvaza.org/core"
+)
+
+// PrefixQuery is a Query matching strings starting with a prefix,
+// as created by HasPrefixQuery. It allows ordered stores to serve it
+// as a range using indexes.
+type PrefixQuery[T any] interface {
+	Query[T]
+
+	// Prefix returns the prefix the values must start with.
+	Prefix() T
+}
+
+// HasPrefixQuery creates a Query that checks if a string starts with
+// the given prefix.
+func HasPrefixQuery[T ~string](prefix T) Query[T] {
+	return &prefixQuery[T]{prefix: prefix}
+}
+
+// prefixQuery is a PrefixQuery.
+type prefixQuery[T ~string] struct {
+	prefix T
+}
+
+// And combines this query with others using logical AND.
+func (q *prefixQuery[T]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](q, others))
+}
+
+// Or combines this query with others using logical OR.
+func (q *prefixQuery[T]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](q, others))
+}
+
+// Match tests if the value starts with the prefix.
+func (q *prefixQuery[T]) Match(v T) bool {
+	return strings.HasPrefix(string(v), string(q.prefix))
+}
+
+// Prefix returns the prefix the values must start with.
+func (q *prefixQuery[T]) Prefix() T { return q.prefix }
+
+// String describes the query, like `HAS PREFIX "foo"`.
+func (q *prefixQuery[T]) String() string {
+	return fmt.Sprintf("HAS PREFIX %q", string(q.prefix))
+}
+
+// HasSuffixQuery creates a Query that checks if a string ends with
+// the given suffix.
+func HasSuffixQuery[T ~string](suffix T) Query[T] {
+	return newStringQuery[T](fmt.Sprintf("HAS SUFFIX %q", string(suffix)),
+		func(v string) bool { return strings.HasSuffix(v, string(suffix)) })
+}
+
+// ContainsQuery creates a Query that checks if a string contains
+// the given substring.
+func ContainsQuery[T ~string](substr T) Query[T] {
+	return newStringQuery[T](fmt.Sprintf("CONTAINS %q", string(substr)),
+		func(v string) bool { return strings.Contains(v, string(substr)) })
+}
+
+// EqualFoldQuery creates a Query that checks if a string is equal to
+// the given one under simple Unicode case-folding.
+func EqualFoldQuery[T ~string](s T) Query[T] {
+	return newStringQuery[T](fmt.Sprintf("EQUAL FOLD %q", string(s)),
+		func(v string) bool { return strings.EqualFold(v, string(s)) })
+}
+
+// RegexpQuery creates a Query that checks if a string matches the given
+// regular expression, compiled once when the query is created.
+// Invalid expressions fail with ErrInvalid.
+func RegexpQuery[T ~string](expr string) (Query[T], error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, core.Wrap(ErrInvalid, err.Error())
+	}
+
+	q := &stringQuery[T]{
+		desc:  fmt.Sprintf("MATCHES %q", expr),
+		match: re.MatchString,
+		costs: costRegexp,
+	}
+	return q, nil
+}
+
+// MustRegexpQuery is like RegexpQuery but panics if the expression
+// can't be compiled.
+func MustRegexpQuery[T ~string](expr string) Query[T] {
+	q, err := RegexpQuery[T](expr)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+// stringQuery matches strings using a function, describing itself
+// with a fixed text.
+type stringQuery[T ~string] struct {
+	desc  string
+	match func(string) bool
+	costs int
+}
+
+func newStringQuery[T ~string](desc string, match func(string) bool) Query[T] {
+	return &stringQuery[T]{desc: desc, match: match, costs: costCompare}
+}
+
+// And combines this query with others using logical AND.
+func (q *stringQuery[T]) And(others ...Query[T]) Query[T] {
+	return ands[T](qJoin[T](q, others))
+}
+
+// Or combines this query with others using logical OR.
+func (q *stringQuery[T]) Or(others ...Query[T]) Query[T] {
+	return ors[T](qJoin[T](q, others))
+}
+
+// Match tests the value.
+func (q *stringQuery[T]) Match(v T) bool { return q.match(string(v)) }
+
+// cost estimates the cost of matching the query.
+func (q *stringQuery[T]) cost() int { return q.costs }
+
+// String describes the query, like `CONTAINS "foo"`.
+func (q *stringQuery[T]) String() string { return q.desc }
@@ -0,0 +1,51 @@
+package behold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tag string
+
+func TestStringQueries(t *testing.T) {
+	values := []tag{"foo", "foobar", "barfoo", "Bar", "BAR", "straße"}
+
+	for _, tc := range []struct {
+		q       Query[tag]
+		matches []tag
+		desc    string
+	}{
+		{HasPrefixQuery[tag]("foo"), []tag{"foo", "foobar"}, `HAS PREFIX "foo"`},
+		{HasSuffixQuery[tag]("foo"), []tag{"foo", "barfoo"}, `HAS SUFFIX "foo"`},
+		{ContainsQuery[tag]("bar"), []tag{"foobar", "barfoo"}, `CONTAINS "bar"`},
+		{EqualFoldQuery[tag]("bar"), []tag{"Bar", "BAR"}, `EQUAL FOLD "bar"`},
+		{EqualFoldQuery[tag]("STRASSE"), nil, `EQUAL FOLD "STRASSE"`},
+		{EqualFoldQuery[tag]("STRAßE"), []tag{"straße"}, `EQUAL FOLD "STRAßE"`},
+		{MustRegexpQuery[tag]("^ba?r"), []tag{"barfoo"}, `MATCHES "^ba?r"`},
+		{MustRegexpQuery[tag]("(?i)^bar$"), []tag{"Bar", "BAR"}, `MATCHES "(?i)^bar$"`},
+	} {
+		var got []tag
+		for _, v := range values {
+			if tc.q.Match(v) {
+				got = append(got, v)
+			}
+		}
+		assert.Equal(t, tc.matches, got, tc.desc)
+		assert.Equal(t, tc.desc, DescribeQuery(tc.q))
+	}
+
+	q := ComposeQuery(func(v tag) tag { return v }, HasPrefixQuery[tag]("foo"))
+	pq, ok := q.(ComposedQuery[tag, tag]).Base().(PrefixQuery[tag])
+	if assert.True(t, ok) {
+		assert.Equal(t, tag("foo"), pq.Prefix())
+	}
+}
+
+func TestRegexpQuery(t *testing.T) {
+	q, err := RegexpQuery[string]("[")
+	assert.Nil(t, q)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	assert.Panics(t, func() { MustRegexpQuery[string]("(") })
+}
@@ -304,3 +304,175 @@ func TestQJoin(t *testing.T) {
 		t.Errorf("Expected length 2, got %d", len(result))
 	}
 }
+
+func TestQueryIntrospection(t *testing.T) {
+	q := ComposeQuery(personAge, GtEqQuery(18))
+
+	cq, ok := q.(ComposedQuery[testPerson, int])
+	if !ok {
+		t.Fatal("ComposeQuery should return a ComposedQuery")
+	}
+
+	if age := cq.Accessor()(testPerson{Age: 42}); age != 42 {
+		t.Errorf("Accessor returned %d, expected 42", age)
+	}
+
+	cmp, ok := cq.Base().(ComparisonQuery[int])
+	if !ok {
+		t.Fatal("GtEqQuery should return a ComparisonQuery")
+	}
+
+	if cmp.Op() != OpGtEq || cmp.Operand() != 18 {
+		t.Errorf("Expected >= 18, got %s %d", cmp.Op(), cmp.Operand())
+	}
+
+	for op, want := range map[Query[int]]CompareOp{
+		EqQuery(1):    OpEq,
+		NotEqQuery(1): OpNotEq,
+		GtQuery(1):    OpGt,
+		GtEqQuery(1):  OpGtEq,
+		LtQuery(1):    OpLt,
+		LtEqQuery(1):  OpLtEq,
+	} {
+		if got := op.(ComparisonQuery[int]).Op(); got != want {
+			t.Errorf("Expected %s, got %s", want, got)
+		}
+	}
+
+	if s := CompareOp(0).String(); s != "CompareOp(0)" {
+		t.Errorf("Unexpected name for invalid operator: %q", s)
+	}
+}
+
+func TestLogicalIntrospection(t *testing.T) {
+	isAdult := ComposeQuery(personAge, GtEqQuery(18))
+	isJohn := ComposeQuery(personName, EqQuery(nameJohn))
+
+	q := MatchAll(isAdult, isJohn.Or(isAdult))
+	lq, ok := q.(LogicalQuery[testPerson])
+	if !ok {
+		t.Fatal("MatchAll should return a LogicalQuery")
+	}
+
+	if lq.Operator() != OpAnd || len(lq.Operands()) != 2 {
+		t.Errorf("Expected AND of 2, got %s of %d", lq.Operator(), len(lq.Operands()))
+	}
+
+	or, ok := lq.Operands()[1].(LogicalQuery[testPerson])
+	if !ok || or.Operator() != OpOr {
+		t.Error("Or should return an OR LogicalQuery")
+	}
+
+	// Operands returns a copy
+	lq.Operands()[0] = nil
+	if lq.Operands()[0] == nil {
+		t.Error("Operands should return a copy")
+	}
+}
+
+func TestDescribeQuery(t *testing.T) {
+	isAdult := ComposeQuery(personAge, GtEqQuery(18))
+	isJohn := ComposeQuery(personName, EqQuery(nameJohn))
+
+	for _, tc := range []struct {
+		q    Query[testPerson]
+		want string
+	}{
+		{isAdult, "behold.personAge >= 18"},
+		{MatchAll(isAdult, nil, isJohn),
+			"(behold.personAge >= 18 AND behold.personName == John)"},
+		{MatchAny(isAdult), "behold.personAge >= 18"},
+		{MatchAny[testPerson](), "false"},
+		{MatchAll[testPerson](), "true"},
+		{QueryFunc[testPerson](nil), "true"},
+		{nil, "nil"},
+	} {
+		if got := DescribeQuery(tc.q); got != tc.want {
+			t.Errorf("Expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestNot(t *testing.T) {
+	isAdult := ComposeQuery(personAge, GtEqQuery(18))
+	adult := testPerson{Name: nameJohn, Age: 30}
+	minor := testPerson{Name: nameAlice, Age: 15}
+
+	q := Not(isAdult)
+	if q.Match(adult) || !q.Match(minor) {
+		t.Error("Not should negate the query")
+	}
+
+	if Not[testPerson](nil).Match(adult) {
+		t.Error("Not(nil) should match nothing")
+	}
+
+	if !Not(Not(isAdult)).Match(adult) {
+		t.Error("double negation should match")
+	}
+
+	lq, ok := q.(LogicalQuery[testPerson])
+	if !ok || lq.Operator() != OpNot || len(lq.Operands()) != 1 {
+		t.Error("Not should return a NOT LogicalQuery with one operand")
+	}
+
+	if s := DescribeQuery(q); s != "NOT behold.personAge >= 18" {
+		t.Errorf("Unexpected description %q", s)
+	}
+}
+
+func TestMatchNone(t *testing.T) {
+	isAdult := ComposeQuery(personAge, GtEqQuery(18))
+	isJohn := ComposeQuery(personName, EqQuery(nameJohn))
+
+	q := MatchNone(isAdult, nil, isJohn)
+	if q.Match(testPerson{Name: nameJohn, Age: 15}) {
+		t.Error("MatchNone should not match if any query matches")
+	}
+	if !q.Match(testPerson{Name: nameAlice, Age: 15}) {
+		t.Error("MatchNone should match if no query matches")
+	}
+
+	if !MatchNone[testPerson]().Match(testPerson{}) {
+		t.Error("empty MatchNone should match everything")
+	}
+
+	if !MatchNone[testPerson](nil).Match(testPerson{}) {
+		t.Error("MatchNone should ignore nil queries")
+	}
+}
+
+func TestXor(t *testing.T) {
+	isAdult := ComposeQuery(personAge, GtEqQuery(18))
+	isJohn := ComposeQuery(personName, EqQuery(nameJohn))
+	isBob := ComposeQuery(personName, EqQuery(nameBob))
+
+	q := Xor(isAdult, nil, isJohn)
+	for _, tc := range []struct {
+		p    testPerson
+		want bool
+	}{
+		{testPerson{Name: nameJohn, Age: 30}, false},
+		{testPerson{Name: nameJohn, Age: 15}, true},
+		{testPerson{Name: nameAlice, Age: 30}, true},
+		{testPerson{Name: nameAlice, Age: 15}, false},
+	} {
+		if got := q.Match(tc.p); got != tc.want {
+			t.Errorf("Xor(%v) = %v, expected %v", tc.p, got, tc.want)
+		}
+	}
+
+	// odd number of matches
+	if !Xor(isAdult, isJohn, isBob.Or(isJohn)).Match(testPerson{Name: nameJohn, Age: 30}) {
+		t.Error("Xor should match an odd number of matches")
+	}
+
+	if Xor[testPerson]().Match(testPerson{}) {
+		t.Error("empty Xor should match nothing")
+	}
+
+	lq, ok := q.(LogicalQuery[testPerson])
+	if !ok || lq.Operator() != OpXor {
+		t.Error("Xor should return a XOR LogicalQuery")
+	}
+}
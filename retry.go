@@ -0,0 +1,118 @@
+package behold
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"darvaza.org/core"
+)
+
+// DefaultMaxAttempts is the number of attempts made by UpdateWithRetry
+// when RetryOptions.MaxAttempts is zero.
+const DefaultMaxAttempts = 10
+
+// RetryOptions controls how UpdateWithRetry repeats an Update that
+// failed with a conflict.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of times the Update is run,
+	// or zero for DefaultMaxAttempts.
+	MaxAttempts int
+
+	// Backoff returns how long to wait after the given failed attempt,
+	// counting from 1. If nil, ExponentialBackoff(time.Millisecond,
+	// 100*time.Millisecond) is used.
+	Backoff func(attempt int) time.Duration
+}
+
+// Validate checks the options are valid.
+func (opts RetryOptions) Validate() error {
+	if opts.MaxAttempts < 0 {
+		return core.Wrap(ErrInvalid, "negative max attempts")
+	}
+	return nil
+}
+
+// ExponentialBackoff returns a backoff function doubling the delay after
+// every attempt, starting at base and capped at limit. Half of each delay
+// is randomised so conflicting writers don't retry in lockstep. Attempts
+// below 1 are treated as the first.
+func ExponentialBackoff(base, limit time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		attempt = max(attempt, 1)
+
+		d := limit
+		if attempt < 63 && base < limit>>(attempt-1) {
+			d = base << (attempt - 1)
+		}
+
+		if half := d / 2; half > 0 {
+			d = half + rand.N(half)
+		}
+		return d
+	}
+}
+
+var defaultBackoff = ExponentialBackoff(time.Millisecond, 100*time.Millisecond)
+
+// UpdateWithRetry runs an Update on the store, running it again after a
+// backoff while it fails with ErrConflict, up to opts.MaxAttempts times.
+// It returns the number of attempts made and the error of the last one.
+// Retrying stops early if the context ends, or if its deadline would pass
+// before the next attempt, returning the last conflict.
+//
+// fn must have no side effects besides the changes made through the
+// transaction, as it may be called more than once.
+func UpdateWithRetry[K comparable, V any](ctx context.Context, s Store[K, V], opts RetryOptions,
+	fn func(Tx[K, V]) error, locks ...Mutex) (int, error) {
+	switch {
+	case s == nil:
+		return 0, ErrNilReceiver
+	case ctx == nil, fn == nil:
+		return 0, ErrInvalid
+	}
+
+	if err := opts.Validate(); err != nil {
+		return 0, err
+	}
+
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	backoff := opts.Backoff
+	if backoff == nil {
+		backoff = defaultBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := s.Update(ctx, fn, locks...)
+		if err == nil || !errors.Is(err, ErrConflict) || attempt == maxAttempts {
+			return attempt, err
+		}
+
+		if !sleepCtx(ctx, backoff(attempt)) {
+			return attempt, err
+		}
+	}
+}
+
+// sleepCtx waits for the given duration, returning false without
+// waiting if the context would end first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < d {
+		return false
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,112 @@
+package behold
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// conflictStore is a Store whose Updates fail with a conflict
+// a given number of times before succeeding.
+type conflictStore struct {
+	Store[string, int]
+
+	conflicts int
+	calls     int
+}
+
+func (s *conflictStore) Update(_ context.Context, fn func(Tx[string, int]) error, _ ...Mutex) error {
+	s.calls++
+	if err := fn(nil); err != nil {
+		return err
+	}
+
+	if s.calls <= s.conflicts {
+		return NewConflictError("key")
+	}
+	return nil
+}
+
+func noBackoff(int) time.Duration { return 0 }
+
+func TestUpdateWithRetry(t *testing.T) {
+	ctx := context.Background()
+	fn := func(Tx[string, int]) error { return nil }
+	opts := RetryOptions{MaxAttempts: 3, Backoff: noBackoff}
+
+	s := &conflictStore{conflicts: 2}
+	n, err := UpdateWithRetry[string, int](ctx, s, opts, fn)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	s = &conflictStore{conflicts: 5}
+	n, err = UpdateWithRetry[string, int](ctx, s, opts, fn)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 3, n)
+
+	// other errors aren't retried
+	errAbort := errors.New("abort")
+	s = &conflictStore{conflicts: 5}
+	n, err = UpdateWithRetry[string, int](ctx, s, opts, func(Tx[string, int]) error { return errAbort })
+	assert.ErrorIs(t, err, errAbort)
+	assert.Equal(t, 1, n)
+
+	// default attempts
+	s = &conflictStore{conflicts: 100}
+	n, err = UpdateWithRetry[string, int](ctx, s, RetryOptions{Backoff: noBackoff}, fn)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, DefaultMaxAttempts, n)
+
+	_, err = UpdateWithRetry[string, int](ctx, s, RetryOptions{MaxAttempts: -1}, fn)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = UpdateWithRetry[string, int](ctx, nil, opts, fn)
+	assert.ErrorIs(t, err, ErrNilReceiver)
+}
+
+func TestUpdateWithRetryDeadline(t *testing.T) {
+	fn := func(Tx[string, int]) error { return nil }
+	opts := RetryOptions{
+		MaxAttempts: 10,
+		Backoff:     func(int) time.Duration { return time.Hour },
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	s := &conflictStore{conflicts: 5}
+	n, err := UpdateWithRetry[string, int](ctx, s, opts, fn)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 1, n)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	opts.Backoff = func(int) time.Duration {
+		cancel()
+		return time.Hour
+	}
+
+	s = &conflictStore{conflicts: 5}
+	n, err = UpdateWithRetry[string, int](ctx, s, opts, fn)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, 1, n)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := ExponentialBackoff(10*time.Millisecond, time.Second)
+
+	for attempt, want := range map[int]time.Duration{
+		-1:  10 * time.Millisecond,
+		0:   10 * time.Millisecond,
+		1:   10 * time.Millisecond,
+		2:   20 * time.Millisecond,
+		4:   80 * time.Millisecond,
+		8:   time.Second,
+		100: time.Second,
+	} {
+		d := backoff(attempt)
+		assert.GreaterOrEqual(t, d, want/2, attempt)
+		assert.Less(t, d, want, attempt)
+	}
+}
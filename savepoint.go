@@ -0,0 +1,64 @@
+package behold
+
+import (
+	"darvaza.org/core"
+)
+
+// Savepoint identifies the state of the pending changes of a
+// SavepointTx at a given moment.
+type Savepoint uint64
+
+// SavepointTx is a read-write Tx able to discard part of its pending
+// changes, going back to a previous state without aborting.
+//
+// Type Parameters:
+//   - K comparable: The key type, matching the store's key type
+//   - V any: The value type, matching the store's value type
+type SavepointTx[K comparable, V any] interface {
+	Tx[K, V]
+
+	// Savepoint marks the current state of the pending changes.
+	// Read-only transactions fail with ErrReadOnlyTx.
+	Savepoint() (Savepoint, error)
+
+	// RollbackTo discards the changes made after the savepoint, which
+	// stays valid, and forgets savepoints marked after it. Unknown or
+	// forgotten savepoints fail with ErrInvalid.
+	RollbackTo(sp Savepoint) error
+
+	// Release forgets the savepoint and those marked after it, keeping
+	// the changes made since. Unknown or forgotten savepoints fail
+	// with ErrInvalid.
+	Release(sp Savepoint) error
+}
+
+// Nested runs fn within a savepoint of the transaction, discarding the
+// changes fn made if it fails, and returning its error. The savepoint
+// is released once fn returns. Transactions not implementing
+// SavepointTx fail with ErrInvalid.
+func Nested[K comparable, V any](tx Tx[K, V], fn func(Tx[K, V]) error) error {
+	switch {
+	case tx == nil:
+		return ErrNilReceiver
+	case fn == nil:
+		return ErrInvalid
+	}
+
+	stx, ok := tx.(SavepointTx[K, V])
+	if !ok {
+		return core.Wrap(ErrInvalid, "savepoints not supported")
+	}
+
+	sp, err := stx.Savepoint()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if e2 := stx.RollbackTo(sp); e2 != nil {
+			return e2
+		}
+		return core.CoalesceError(stx.Release(sp), err)
+	}
+	return stx.Release(sp)
+}
@@ -0,0 +1,15 @@
+package behold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNestedUnsupported(t *testing.T) {
+	fn := func(Tx[string, int]) error { return nil }
+
+	assert.ErrorIs(t, Nested(&sliceTx{}, fn), ErrInvalid)
+	assert.ErrorIs(t, Nested[string, int](nil, fn), ErrNilReceiver)
+	assert.ErrorIs(t, Nested[string, int](&sliceTx{}, nil), ErrInvalid)
+}
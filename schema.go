@@ -0,0 +1,270 @@
+package behold
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+
+	"darvaza.org/core"
+)
+
+// Schema describes the fields of values of type V by name, allowing
+// queries to be built from their textual or serialised form.
+// Fields are registered using AddField.
+type Schema[V any] struct {
+	fields map[string]Field[V]
+}
+
+// NewSchema creates a new Schema without fields.
+func NewSchema[V any]() *Schema[V] {
+	return &Schema[V]{fields: make(map[string]Field[V])}
+}
+
+// Field is a named field of a Schema, able to build queries
+// comparing its value.
+type Field[V any] interface {
+	// Name returns the name of the field.
+	Name() string
+
+	// Query returns a Query comparing the field against the operand,
+	// converted to the field's type. Strings can only be compared against
+	// strings, and numbers against numbers that fit in the field's type,
+	// failing with ErrInvalid otherwise.
+	Query(op CompareOp, operand any) (Query[V], error)
+}
+
+// AddField registers a field of the schema, whose value is the result of
+// the accessor function. Names must be identifiers, optionally
+// dot-separated. Use AddAccessor for indexes to serve the queries
+// built by the field.
+func AddField[V any, X core.Ordered](s *Schema[V], name string, fn func(V) X) error {
+	var a *Accessor[V, X]
+	if fn != nil {
+		a = NewAccessor(fn)
+	}
+	return AddAccessor(s, name, a)
+}
+
+// AddAccessor registers a field of the schema, whose value is given by
+// the Accessor. Queries built by the field are built by the Accessor,
+// so indexes created with it can serve them.
+// Names must be identifiers, optionally dot-separated.
+func AddAccessor[V any, X core.Ordered](s *Schema[V], name string, a *Accessor[V, X]) error {
+	switch {
+	case s == nil:
+		return ErrNilReceiver
+	case a == nil, !validFieldName(name):
+		return ErrInvalid
+	}
+
+	if _, ok := s.fields[name]; ok {
+		return core.Wrapf(core.ErrExists, "field %q", name)
+	}
+
+	if s.fields == nil {
+		s.fields = make(map[string]Field[V])
+	}
+	s.fields[name] = &field[V, X]{name: name, acc: a}
+	return nil
+}
+
+// Field returns the field registered with the given name.
+func (s *Schema[V]) Field(name string) (Field[V], bool) {
+	if s == nil {
+		return nil, false
+	}
+
+	f, ok := s.fields[name]
+	return f, ok
+}
+
+// Fields returns the sorted names of the registered fields.
+func (s *Schema[V]) Fields() []string {
+	if s == nil {
+		return nil
+	}
+
+	names := core.Keys(s.fields)
+	sort.Strings(names)
+	return names
+}
+
+// validFieldName tells if the name is a sequence of identifiers
+// separated by dots.
+func validFieldName(name string) bool {
+	start := true
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			start = false
+		case c >= '0' && c <= '9' && !start:
+		case c == '.' && !start && i < len(name)-1:
+			start = true
+		default:
+			return false
+		}
+	}
+	return name != "" && !start
+}
+
+// fieldComparer is a Field able to recognise the queries it builds.
+type fieldComparer[V any] interface {
+	Field[V]
+
+	// comparison returns the comparison performed by the query
+	// if it was built by the field.
+	comparison(q Query[V]) (CompareOp, any, bool)
+}
+
+// fieldQuery is a query built by a field, tagged with it so Expr
+// can describe it.
+type fieldQuery[V any, X core.Ordered] struct {
+	*composeQuery[V, X]
+	field *field[V, X]
+}
+
+// And combines this query with others using logical AND.
+func (q *fieldQuery[V, X]) And(others ...Query[V]) Query[V] {
+	return ands[V](qJoin[V](q, others))
+}
+
+// Or combines this query with others using logical OR.
+func (q *fieldQuery[V, X]) Or(others ...Query[V]) Query[V] {
+	return ors[V](qJoin[V](q, others))
+}
+
+// field is a Field of type X.
+type field[V any, X core.Ordered] struct {
+	name string
+	acc  *Accessor[V, X]
+}
+
+func (f *field[V, X]) Name() string { return f.name }
+
+func (f *field[V, X]) Query(op CompareOp, operand any) (Query[V], error) {
+	x, err := convertOperand[X](operand)
+	if err != nil {
+		return nil, core.Wrapf(err, "field %q", f.name)
+	}
+
+	var q Query[X]
+	switch op {
+	case OpEq:
+		q = EqQuery(x)
+	case OpNotEq:
+		q = NotEqQuery(x)
+	case OpGt:
+		q = GtQuery(x)
+	case OpGtEq:
+		q = GtEqQuery(x)
+	case OpLt:
+		q = LtQuery(x)
+	case OpLtEq:
+		q = LtEqQuery(x)
+	default:
+		return nil, core.Wrapf(ErrInvalid, "invalid operator %s", op)
+	}
+	return &fieldQuery[V, X]{
+		composeQuery: &composeQuery[V, X]{fn: f.acc.fn, query: q, acc: f.acc},
+		field:        f,
+	}, nil
+}
+
+func (f *field[V, X]) comparison(q Query[V]) (CompareOp, any, bool) {
+	cq, ok := q.(*fieldQuery[V, X])
+	if !ok || cq.field != f {
+		return 0, nil, false
+	}
+
+	cmp, ok := cq.Base().(ComparisonQuery[X])
+	if !ok {
+		return 0, nil, false
+	}
+	return cmp.Op(), cmp.Operand(), true
+}
+
+// convertOperand converts a string or number to the given type, failing
+// with ErrInvalid if the kinds don't match or the number doesn't fit.
+func convertOperand[X core.Ordered](operand any) (X, error) {
+	var out X
+	if x, ok := operand.(X); ok {
+		return x, nil
+	}
+
+	rv := reflect.ValueOf(&out).Elem()
+	in := reflect.ValueOf(operand)
+	switch {
+	case !in.IsValid():
+		// nil
+	case isStringKind(rv.Kind()) && isStringKind(in.Kind()):
+		rv.SetString(in.String())
+		return out, nil
+	case in.CanFloat() && !rv.CanFloat():
+		// no fractions on integers
+	case isNumberKind(rv.Kind()) && isNumberKind(in.Kind()):
+		if convertNumber(rv, in) {
+			return out, nil
+		}
+		return out, core.Wrapf(ErrInvalid, "%v out of range for %s", operand, rv.Type())
+	}
+
+	return out, core.Wrapf(ErrInvalid, "can't compare %s against %s",
+		rv.Type(), describeOperand(operand))
+}
+
+// convertNumber stores the number in rv if it fits.
+func convertNumber(rv, in reflect.Value) bool {
+	switch {
+	case rv.CanInt():
+		switch {
+		case in.CanInt() && !rv.OverflowInt(in.Int()):
+			rv.SetInt(in.Int())
+			return true
+		case in.CanUint() && in.Uint() <= uint64(1<<63-1) && !rv.OverflowInt(int64(in.Uint())):
+			rv.SetInt(int64(in.Uint()))
+			return true
+		}
+	case rv.CanUint():
+		switch {
+		case in.CanInt() && in.Int() >= 0 && !rv.OverflowUint(uint64(in.Int())):
+			rv.SetUint(uint64(in.Int()))
+			return true
+		case in.CanUint() && !rv.OverflowUint(in.Uint()):
+			rv.SetUint(in.Uint())
+			return true
+		}
+	case rv.CanFloat():
+		switch {
+		case in.CanFloat():
+			rv.SetFloat(in.Float())
+		case in.CanInt():
+			rv.SetFloat(float64(in.Int()))
+		default:
+			rv.SetFloat(float64(in.Uint()))
+		}
+		return true
+	}
+	return false
+}
+
+func isStringKind(k reflect.Kind) bool {
+	return k == reflect.String
+}
+
+var numberKinds = []reflect.Kind{
+	reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+	reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+	reflect.Float32, reflect.Float64,
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	return slices.Contains(numberKinds, k)
+}
+
+func describeOperand(operand any) string {
+	if operand == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%T %#v", operand, operand)
+}
@@ -0,0 +1,51 @@
+package behold
+
+import (
+	"testing"
+
+	"darvaza.org/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	s := NewSchema[testPerson]()
+	require.NoError(t, AddField(s, "age", personAge))
+	require.NoError(t, AddField(s, "person.name", personName))
+
+	assert.ErrorIs(t, AddField(s, "age", personAge), core.ErrExists)
+	for _, name := range []string{"", "1age", "age.", ".age", "a..b", "a-b"} {
+		assert.ErrorIs(t, AddField(s, name, personAge), ErrInvalid, "name %q", name)
+	}
+	assert.Equal(t, []string{"age", "person.name"}, s.Fields())
+
+	f, ok := s.Field("age")
+	require.True(t, ok)
+	assert.Equal(t, "age", f.Name())
+
+	for _, operand := range []any{18, int8(18), uint64(18), int64(18)} {
+		q, err := f.Query(OpGtEq, operand)
+		require.NoError(t, err)
+		assert.True(t, q.Match(testPerson{Age: 18}))
+		assert.False(t, q.Match(testPerson{Age: 17}))
+
+		// built like ComposeQuery(personAge, GtEqQuery(18))
+		assert.Equal(t, "behold.personAge >= 18", DescribeQuery(q))
+	}
+
+	for _, operand := range []any{"18", 1.5, uint64(1 << 63), nil} {
+		_, err := f.Query(OpEq, operand)
+		assert.ErrorIs(t, err, ErrInvalid, "operand %v", operand)
+	}
+
+	_, err := f.Query(CompareOp(0), 1)
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	f, _ = s.Field("person.name")
+	q, err := f.Query(OpEq, nameJohn)
+	require.NoError(t, err)
+	assert.True(t, q.Match(testPerson{Name: nameJohn}))
+
+	_, ok = s.Field("unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,279 @@
+package behold
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Simplify returns a query equivalent to the given one, but cheaper to
+// match and easier for stores to plan. Nested AND, OR and XOR queries
+// are flattened, nil and constant branches removed, double negations
+// cancelled and identical operands deduplicated. The operands of AND
+// and OR queries are then ordered by their estimated cost, so cheap
+// comparisons are tried before opaque functions, keeping their order
+// otherwise. As a consequence, an operand of AND can't rely on those
+// preceding it to guard it, like a check for a nil pointer followed
+// by a function dereferencing it, as it may be tried first.
+//
+// Queries built by the same Accessor over identical base queries are
+// identical, but those built by ComposeQuery never are, as functions
+// can't be compared.
+//
+// Queries that always match are simplified to MatchAll(), and those that
+// never match to MatchAny().
+func Simplify[T any](q Query[T]) Query[T] {
+	q, c := simplify(q)
+	switch c {
+	case alwaysTrue:
+		return MatchAll[T]()
+	case alwaysFalse:
+		return MatchAny[T]()
+	default:
+		return q
+	}
+}
+
+// constness tells if a simplified query is constant.
+type constness int
+
+const (
+	notConstant constness = iota
+	alwaysTrue
+	alwaysFalse
+)
+
+// simplify simplifies a query, returning nil if it's constant.
+// A nil query matches everything.
+func simplify[T any](q Query[T]) (Query[T], constness) {
+	switch v := q.(type) {
+	case nil:
+		return nil, alwaysTrue
+	case QueryFunc[T]:
+		if v == nil {
+			return nil, alwaysTrue
+		}
+	case LogicalQuery[T]:
+		switch v.Operator() {
+		case OpAnd:
+			return simplifyJunction(v.Operands(), OpAnd, alwaysTrue, alwaysFalse)
+		case OpOr:
+			return simplifyJunction(v.Operands(), OpOr, alwaysFalse, alwaysTrue)
+		case OpXor:
+			return simplifyXor(v.Operands())
+		case OpNot:
+			return simplifyNot(v.Operands()[0])
+		}
+	}
+	return q, notConstant
+}
+
+// simplifyJunction simplifies the operands of an AND or OR query, given
+// the constant that can be ignored and the one deciding the result.
+func simplifyJunction[T any](operands []Query[T], op LogicalOp,
+	neutral, absorbing constness) (Query[T], constness) {
+	out := make([]Query[T], 0, len(operands))
+	for _, q := range operands {
+		if q == nil {
+			// ignored when matching
+			continue
+		}
+
+		s, c := simplify(q)
+		switch {
+		case c == absorbing:
+			return nil, absorbing
+		case c == neutral:
+			continue
+		}
+
+		if lq, ok := s.(LogicalQuery[T]); ok && lq.Operator() == op {
+			out = append(out, lq.Operands()...)
+		} else {
+			out = append(out, s)
+		}
+	}
+
+	out = dedupQueries(out)
+	slices.SortStableFunc(out, func(a, b Query[T]) int {
+		return queryCost(a) - queryCost(b)
+	})
+
+	switch {
+	case len(out) == 0:
+		return nil, neutral
+	case len(out) == 1:
+		return out[0], notConstant
+	case op == OpAnd:
+		return ands[T](out), notConstant
+	default:
+		return ors[T](out), notConstant
+	}
+}
+
+// simplifyXor simplifies the operands of a XOR query. Operands that
+// always match negate the result, and identical pairs cancel out.
+func simplifyXor[T any](operands []Query[T]) (Query[T], constness) {
+	var negated bool
+	out := make([]Query[T], 0, len(operands))
+	for _, q := range operands {
+		if q == nil {
+			// ignored when matching
+			continue
+		}
+
+		s, c := simplify(q)
+		switch c {
+		case alwaysTrue:
+			negated = !negated
+			continue
+		case alwaysFalse:
+			continue
+		}
+
+		if lq, ok := s.(LogicalQuery[T]); ok && lq.Operator() == OpXor {
+			out = append(out, lq.Operands()...)
+		} else {
+			out = append(out, s)
+		}
+	}
+
+	out = cancelPairs(out)
+
+	var q Query[T]
+	switch len(out) {
+	case 0:
+		if negated {
+			return nil, alwaysTrue
+		}
+		return nil, alwaysFalse
+	case 1:
+		q = out[0]
+	default:
+		q = xors[T](out)
+	}
+
+	if negated {
+		return simplifyNot(q)
+	}
+	return q, notConstant
+}
+
+// simplifyNot simplifies the negation of a query.
+func simplifyNot[T any](q Query[T]) (Query[T], constness) {
+	s, c := simplify(q)
+	switch c {
+	case alwaysTrue:
+		return nil, alwaysFalse
+	case alwaysFalse:
+		return nil, alwaysTrue
+	}
+
+	if lq, ok := s.(LogicalQuery[T]); ok && lq.Operator() == OpNot {
+		// double negation
+		return lq.Operands()[0], notConstant
+	}
+	return &notQuery[T]{query: s}, notConstant
+}
+
+// dedupQueries removes the queries identical to a previous one.
+func dedupQueries[T any](queries []Query[T]) []Query[T] {
+	out := queries[:0]
+	for _, q := range queries {
+		if !slices.ContainsFunc(out, func(p Query[T]) bool { return identical(p, q) }) {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+// cancelPairs removes pairs of identical queries.
+func cancelPairs[T any](queries []Query[T]) []Query[T] {
+	out := make([]Query[T], 0, len(queries))
+	for _, q := range queries {
+		i := slices.IndexFunc(out, func(p Query[T]) bool { return identical(p, q) })
+		if i < 0 {
+			out = append(out, q)
+		} else {
+			out = slices.Delete(out, i, i+1)
+		}
+	}
+	return out
+}
+
+// sameQuery is implemented by queries able to tell if another is
+// identical to them.
+type sameQuery[T any] interface {
+	sameAs(other Query[T]) bool
+}
+
+// identical tells if two queries are known to be the same, either being
+// equal values or introspectable queries of the same type and operands.
+func identical[T any](a, b Query[T]) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	switch {
+	case !va.IsValid() || !vb.IsValid():
+		return va.IsValid() == vb.IsValid()
+	case va.Type() != vb.Type():
+		return false
+	case va.Comparable() && vb.Comparable() && va.Equal(vb):
+		return true
+	}
+
+	switch a := a.(type) {
+	case sameQuery[T]:
+		return a.sameAs(b)
+	case LogicalQuery[T]:
+		b := b.(LogicalQuery[T])
+		return a.Operator() == b.Operator() &&
+			slices.EqualFunc(a.Operands(), b.Operands(), identical[T])
+	case ComparisonQuery[T]:
+		b := b.(ComparisonQuery[T])
+		return a.Op() == b.Op() && reflect.DeepEqual(a.Operand(), b.Operand())
+	case RangeQuery[T]:
+		b := b.(RangeQuery[T])
+		loA, hiA := a.Bounds()
+		loB, hiB := b.Bounds()
+		return a.Comparison() == nil && b.Comparison() == nil &&
+			reflect.DeepEqual(loA, loB) && reflect.DeepEqual(hiA, hiB)
+	case PrefixQuery[T]:
+		return reflect.DeepEqual(a.Prefix(), b.(PrefixQuery[T]).Prefix())
+	default:
+		return false
+	}
+}
+
+// Estimated relative costs of matching queries.
+const (
+	costCompare = 1
+	costSet     = 2
+	costCall    = 2
+	costUnknown = 4
+	costRegexp  = 8
+)
+
+// coster is implemented by queries estimating their own cost.
+type coster interface {
+	cost() int
+}
+
+// queryCost estimates the relative cost of matching a query.
+func queryCost[T any](q Query[T]) int {
+	switch v := q.(type) {
+	case coster:
+		return v.cost()
+	case LogicalQuery[T]:
+		n := 1
+		for _, op := range v.Operands() {
+			if op != nil {
+				n += queryCost(op)
+			}
+		}
+		return n
+	case ComparisonQuery[T], RangeQuery[T], PrefixQuery[T]:
+		return costCompare
+	case SetQuery[T]:
+		return costSet
+	default:
+		return costUnknown
+	}
+}
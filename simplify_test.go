@@ -0,0 +1,84 @@
+package behold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func half(v int) int { return v / 2 }
+
+func TestSimplify(t *testing.T) {
+	odd := QueryFunc[int](isOdd)
+	gt2 := GtQuery(2)
+	lt8 := LtQuery(8)
+	byHalf := NewAccessor(half)
+
+	for _, tc := range []struct {
+		name string
+		q    Query[int]
+		desc string
+	}{
+		{"Nil", nil, "true"},
+		{"NilFunc", QueryFunc[int](nil), "true"},
+		{"Leaf", gt2, "> 2"},
+		{"Flatten", odd.And(gt2.And(lt8)), "(> 2 AND < 8 AND behold.isOdd)"},
+		{"FlattenOr", MatchAny(MatchAny(gt2, nil), MatchAny(lt8)), "(> 2 OR < 8)"},
+		{"Single", MatchAll(nil, MatchAny(gt2)), "> 2"},
+		{"True", MatchAll(gt2, MatchAll[int]()), "> 2"},
+		{"False", MatchAll(gt2, MatchAny[int]()), "false"},
+		{"OrTrue", MatchAny(gt2, QueryFunc[int](nil)), "true"},
+		{"OrFalse", MatchAny(gt2, MatchNone[int](nil, QueryFunc[int](nil))), "> 2"},
+		{"Dedup", MatchAll(gt2, lt8, GtQuery(2), gt2), "(> 2 AND < 8)"},
+		{"DedupLogical", MatchAny(Not(gt2), Not(GtQuery(2))), "NOT > 2"},
+		{"DedupAccessor", MatchAll(byHalf.Query(GtQuery(1)), byHalf.Query(GtQuery(1))),
+			"behold.half > 1"},
+		{"DedupAccessorBase", MatchAll(byHalf.Query(GtQuery(1)), byHalf.Query(LtQuery(3))),
+			"(behold.half > 1 AND behold.half < 3)"},
+		{"DedupOtherAccessor", MatchAll(byHalf.Query(GtQuery(1)), NewAccessor(half).Query(GtQuery(1))),
+			"(behold.half > 1 AND behold.half > 1)"},
+		{"DedupComposed", MatchAll(ComposeQuery(half, GtQuery(1)), ComposeQuery(half, GtQuery(1))),
+			"(behold.half > 1 AND behold.half > 1)"},
+		{"NotNot", Not(Not(gt2)), "> 2"},
+		{"NotFalse", Not[int](MatchAny[int]()), "true"},
+		{"NotNil", Not[int](nil), "false"},
+		{"XorCancel", Xor(gt2, lt8, GtQuery(2)), "< 8"},
+		{"XorTrue", Xor(gt2, MatchAll[int]()), "NOT > 2"},
+		{"XorFlatten", Xor(gt2, Xor(lt8, odd)), "(> 2 XOR < 8 XOR behold.isOdd)"},
+		{"XorEmpty", Xor(gt2, gt2), "false"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Simplify(tc.q)
+			assert.Equal(t, tc.desc, DescribeQuery(s))
+
+			for v := 0; v < 10; v++ {
+				want := tc.q == nil || tc.q.Match(v)
+				assert.Equal(t, want, s.Match(v), "%v", v)
+			}
+		})
+	}
+}
+
+func TestSimplifyCost(t *testing.T) {
+	q := MatchAll(
+		MustRegexpQuery[string]("o"),
+		QueryFunc[string](func(s string) bool { return s != "" }),
+		ComposeQuery(func(s string) int { return len(s) }, GtQuery(1)),
+		InQuery("foo", "bar"),
+		HasPrefixQuery("f"),
+	)
+
+	s, ok := Simplify(q).(LogicalQuery[string])
+	if assert.True(t, ok) {
+		var descs []string
+		for _, q := range s.Operands() {
+			descs = append(descs, DescribeQuery(q))
+		}
+		assert.Equal(t, `HAS PREFIX "f"`, descs[0])
+		assert.Equal(t, "IN (foo, bar)", descs[1])
+		assert.Contains(t, descs[2], "> 1")
+		assert.Equal(t, `MATCHES "o"`, descs[4])
+	}
+	assert.True(t, s.Match("foo"))
+	assert.False(t, s.Match("bar"))
+}
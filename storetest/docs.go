@@ -0,0 +1,3 @@
+// Package storetest provides a conformance test suite for
+// implementations of behold.Store.
+package storetest
@@ -0,0 +1,163 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/amery/behold"
+)
+
+// Suite describes how to run the conformance tests against
+// a behold.Store implementation.
+type Suite[K comparable, V any] struct {
+	// New returns a new empty Store. It will be closed by the
+	// tests unless already closed.
+	New func(t *testing.T) behold.Store[K, V]
+
+	// Key returns a key for the given index. Different indexes
+	// must give different keys.
+	Key func(i int) K
+
+	// Value returns a value for the given index. Different indexes
+	// must give different values.
+	Value func(i int) V
+
+	// Equal compares values. If nil, reflect.DeepEqual is used.
+	Equal func(a, b V) bool
+}
+
+// RunStoreTests runs the conformance tests against stores of
+// string keys and int values created by the given factory.
+func RunStoreTests(t *testing.T, factory func(t *testing.T) behold.Store[string, int]) {
+	s := Suite[string, int]{
+		New:   factory,
+		Key:   func(i int) string { return "key" + strconv.Itoa(i) },
+		Value: func(i int) int { return i },
+	}
+	s.Run(t)
+}
+
+// Run runs the conformance tests as subtests of t.
+func (s Suite[K, V]) Run(t *testing.T) {
+	if s.New == nil || s.Key == nil || s.Value == nil {
+		t.Fatal("incomplete storetest.Suite")
+	}
+
+	for _, tc := range []struct {
+		name string
+		fn   func(*testing.T)
+	}{
+		{"SetGetDelete", s.testSetGetDelete},
+		{"NotFound", s.testNotFound},
+		{"Append", s.testAppend},
+		{"ReadOnly", s.testReadOnly},
+		{"Commit", s.testCommit},
+		{"Rollback", s.testRollback},
+		{"ClosedTx", s.testClosedTx},
+		{"Version", s.testVersion},
+		{"ForEach", s.testForEach},
+		{"ForEachMatch", s.testForEachMatch},
+		{"Context", s.testContext},
+		{"Locks", s.testLocks},
+		{"Concurrency", s.testConcurrency},
+		{"Close", s.testClose},
+	} {
+		t.Run(tc.name, tc.fn)
+	}
+}
+
+// store creates a new Store, closing it when the test ends.
+func (s Suite[K, V]) store(t *testing.T) behold.Store[K, V] {
+	t.Helper()
+
+	st := s.New(t)
+	if st == nil {
+		t.Fatal("Suite.New returned nil")
+	}
+
+	t.Cleanup(func() { _ = st.Close() })
+	return st
+}
+
+// fill stores n entries in a single transaction.
+func (s Suite[K, V]) fill(t *testing.T, st behold.Store[K, V], n int) {
+	t.Helper()
+
+	err := st.Update(context.Background(), func(tx behold.Tx[K, V]) error {
+		for i := 0; i < n; i++ {
+			if err := tx.Set(s.Key(i), s.Value(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+}
+
+// view runs a read-only transaction failing the test on error.
+func (s Suite[K, V]) view(t *testing.T, st behold.Store[K, V], fn func(behold.Tx[K, V])) {
+	t.Helper()
+
+	err := st.View(context.Background(), func(tx behold.Tx[K, V]) error {
+		fn(tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+}
+
+// update runs a read-write transaction failing the test on error.
+func (s Suite[K, V]) update(t *testing.T, st behold.Store[K, V], fn func(behold.Tx[K, V]) error) {
+	t.Helper()
+
+	if err := st.Update(context.Background(), fn); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+}
+
+// expect checks the value of the i-th key, or its absence
+// if present is false.
+func (s Suite[K, V]) expect(t *testing.T, tx behold.Tx[K, V], i int, value V, present bool) {
+	t.Helper()
+
+	v, err := tx.Get(s.Key(i))
+	switch {
+	case !present && err == nil:
+		t.Errorf("Get(%v): expected error, got %v", s.Key(i), v)
+	case !present:
+		checkNotFound(t, "Get", err, s.Key(i))
+	case err != nil:
+		t.Errorf("Get(%v): unexpected error: %v", s.Key(i), err)
+	case !s.equal(v, value):
+		t.Errorf("Get(%v): expected %v, got %v", s.Key(i), value, v)
+	}
+}
+
+// checkNotFound checks err is a KeyError for the given key
+// wrapping ErrNotFound.
+func checkNotFound[K comparable](t *testing.T, op string, err error, key K) {
+	t.Helper()
+
+	var ke *behold.KeyError[K]
+	switch {
+	case !errors.Is(err, behold.ErrNotFound):
+		t.Errorf("%s(%v): expected ErrNotFound, got %v", op, key, err)
+	case !errors.As(err, &ke):
+		t.Errorf("%s(%v): expected KeyError, got %T", op, key, err)
+	case ke.Key != key:
+		t.Errorf("%s(%v): KeyError for the wrong key %v", op, key, ke.Key)
+	}
+}
+
+func (s Suite[K, V]) equal(a, b V) bool {
+	if s.Equal != nil {
+		return s.Equal(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
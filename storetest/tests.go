@@ -0,0 +1,436 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/amery/behold"
+)
+
+var errAbort = errors.New("abort")
+
+func (s Suite[K, V]) testSetGetDelete(t *testing.T) {
+	st := s.store(t)
+
+	s.update(t, st, func(tx behold.Tx[K, V]) error {
+		for i := 0; i < 3; i++ {
+			if err := tx.Set(s.Key(i), s.Value(i)); err != nil {
+				return err
+			}
+		}
+
+		// changes are visible within the transaction
+		s.expect(t, tx, 0, s.Value(0), true)
+		if err := tx.Set(s.Key(0), s.Value(10)); err != nil {
+			return err
+		}
+		s.expect(t, tx, 0, s.Value(10), true)
+		return tx.Delete(s.Key(1))
+	})
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		s.expect(t, tx, 0, s.Value(10), true)
+		s.expect(t, tx, 1, s.Value(1), false)
+		s.expect(t, tx, 2, s.Value(2), true)
+	})
+}
+
+func (s Suite[K, V]) testNotFound(t *testing.T) {
+	st := s.store(t)
+	s.fill(t, st, 1)
+
+	s.update(t, st, func(tx behold.Tx[K, V]) error {
+		checkNotFound(t, "Delete", tx.Delete(s.Key(1)), s.Key(1))
+
+		if err := tx.Delete(s.Key(0)); err != nil {
+			return err
+		}
+		checkNotFound(t, "Delete", tx.Delete(s.Key(0)), s.Key(0))
+		s.expect(t, tx, 0, s.Value(0), false)
+		return nil
+	})
+}
+
+func (s Suite[K, V]) testAppend(t *testing.T) {
+	st := s.store(t)
+
+	// appending to a missing key behaves like Set
+	s.update(t, st, func(tx behold.Tx[K, V]) error {
+		return tx.Append(s.Key(0), s.Value(0))
+	})
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		s.expect(t, tx, 0, s.Value(0), true)
+	})
+}
+
+func (s Suite[K, V]) testReadOnly(t *testing.T) {
+	st := s.store(t)
+	s.fill(t, st, 1)
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		for name, err := range map[string]error{
+			"Set":    tx.Set(s.Key(1), s.Value(1)),
+			"Append": tx.Append(s.Key(1), s.Value(1)),
+			"Delete": tx.Delete(s.Key(0)),
+			"Commit": tx.Commit(),
+		} {
+			if !errors.Is(err, behold.ErrReadOnlyTx) {
+				t.Errorf("%s: expected ErrReadOnlyTx, got %v", name, err)
+			}
+		}
+
+		s.expect(t, tx, 0, s.Value(0), true)
+		s.expect(t, tx, 1, s.Value(1), false)
+	})
+}
+
+func (s Suite[K, V]) testCommit(t *testing.T) {
+	st := s.store(t)
+
+	// explicit Commit
+	s.update(t, st, func(tx behold.Tx[K, V]) error {
+		if err := tx.Set(s.Key(0), s.Value(0)); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+
+	// implicit commit when fn returns nil
+	s.update(t, st, func(tx behold.Tx[K, V]) error {
+		return tx.Set(s.Key(1), s.Value(1))
+	})
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		s.expect(t, tx, 0, s.Value(0), true)
+		s.expect(t, tx, 1, s.Value(1), true)
+	})
+}
+
+func (s Suite[K, V]) testRollback(t *testing.T) {
+	st := s.store(t)
+	s.fill(t, st, 1)
+
+	// explicit Close
+	s.update(t, st, func(tx behold.Tx[K, V]) error {
+		if err := tx.Set(s.Key(1), s.Value(1)); err != nil {
+			return err
+		}
+		return tx.Close()
+	})
+
+	// failing fn
+	err := st.Update(context.Background(), func(tx behold.Tx[K, V]) error {
+		if err := tx.Delete(s.Key(0)); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("Update: expected %v, got %v", errAbort, err)
+	}
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		s.expect(t, tx, 0, s.Value(0), true)
+		s.expect(t, tx, 1, s.Value(1), false)
+	})
+}
+
+func (s Suite[K, V]) testClosedTx(t *testing.T) {
+	st := s.store(t)
+	s.fill(t, st, 1)
+
+	var leaked behold.Tx[K, V]
+	s.update(t, st, func(tx behold.Tx[K, V]) error {
+		leaked = tx
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		checkClosedTx(t, "committed", tx, s.Key(0), s.Value(0))
+		return nil
+	})
+	checkClosedTx(t, "returned Update", leaked, s.Key(0), s.Value(0))
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		leaked = tx
+	})
+	if _, err := leaked.Get(s.Key(0)); !errors.Is(err, behold.ErrClosed) {
+		t.Errorf("returned View: Get: expected ErrClosed, got %v", err)
+	}
+}
+
+func checkClosedTx[K comparable, V any](t *testing.T, name string, tx behold.Tx[K, V], key K, value V) {
+	t.Helper()
+
+	_, getErr := tx.Get(key)
+	for op, err := range map[string]error{
+		"Get":    getErr,
+		"Set":    tx.Set(key, value),
+		"Append": tx.Append(key, value),
+		"Delete": tx.Delete(key),
+		"Commit": tx.Commit(),
+		"ForEach": tx.ForEach(func(K, V) bool {
+			return true
+		}),
+	} {
+		if !errors.Is(err, behold.ErrClosed) {
+			t.Errorf("%s: %s: expected ErrClosed, got %v", name, op, err)
+		}
+	}
+}
+
+func (s Suite[K, V]) testVersion(t *testing.T) {
+	st := s.store(t)
+
+	last := st.Version()
+	for i := 0; i < 3; i++ {
+		s.fill(t, st, i+1)
+
+		v := st.Version()
+		if v <= last {
+			t.Errorf("Version didn't increase after commit: %v -> %v", last, v)
+		}
+		last = v
+
+		s.view(t, st, func(tx behold.Tx[K, V]) {
+			if tx.Version() != last {
+				t.Errorf("Tx.Version: expected %v, got %v", last, tx.Version())
+			}
+		})
+	}
+
+	_ = st.Update(context.Background(), func(tx behold.Tx[K, V]) error {
+		if err := tx.Set(s.Key(0), s.Value(10)); err != nil {
+			return err
+		}
+		return errAbort
+	})
+
+	if v := st.Version(); v != last {
+		t.Errorf("Version changed after rollback: %v -> %v", last, v)
+	}
+}
+
+func (s Suite[K, V]) testForEach(t *testing.T) {
+	const n = 10
+
+	st := s.store(t)
+	s.fill(t, st, n)
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		seen := make(map[K]V)
+		err := tx.ForEach(func(k K, v V) bool {
+			seen[k] = v
+			return true
+		})
+		if err != nil {
+			t.Fatalf("ForEach: %v", err)
+		}
+
+		if len(seen) != n {
+			t.Errorf("ForEach: expected %v entries, got %v", n, len(seen))
+		}
+		for i := 0; i < n; i++ {
+			if v, ok := seen[s.Key(i)]; !ok || !s.equal(v, s.Value(i)) {
+				t.Errorf("ForEach: wrong entry for %v", s.Key(i))
+			}
+		}
+
+		// early stop
+		count := 0
+		err = tx.ForEach(func(K, V) bool {
+			count++
+			return count < 3
+		})
+		if err != nil || count != 3 {
+			t.Errorf("ForEach: expected to stop after 3 entries, got %v (%v)", count, err)
+		}
+	})
+}
+
+func (s Suite[K, V]) testForEachMatch(t *testing.T) {
+	const n = 10
+
+	st := s.store(t)
+	s.fill(t, st, n)
+
+	collect := func(name string, forEach func(func(K, V) bool) error) map[K]V {
+		seen := make(map[K]V)
+		if err := forEach(func(k K, v V) bool {
+			seen[k] = v
+			return true
+		}); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		return seen
+	}
+
+	isValue := func(i int) behold.Query[V] {
+		return behold.QueryFunc[V](func(v V) bool {
+			return s.equal(v, s.Value(i))
+		})
+	}
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		seen := collect("ForEachMatch", func(fn func(K, V) bool) error {
+			return tx.ForEachMatch(fn, isValue(1), isValue(3))
+		})
+		s.expectEntries(t, "ForEachMatch", seen, 1, 3)
+
+		seen = collect("ForEachMatch", func(fn func(K, V) bool) error {
+			return tx.ForEachMatch(fn)
+		})
+		if len(seen) != n {
+			t.Errorf("ForEachMatch: expected %v entries, got %v", n, len(seen))
+		}
+
+		seen = collect("ForEachEntry", func(fn func(K, V) bool) error {
+			return tx.ForEachEntry(fn,
+				behold.KeyQuery[K, V](behold.EqQuery(s.Key(2))),
+				behold.ValueQuery[K](isValue(4)))
+		})
+		s.expectEntries(t, "ForEachEntry", seen, 2, 4)
+	})
+}
+
+// expectEntries checks the given entries are exactly
+// the ones of the given indexes.
+func (s Suite[K, V]) expectEntries(t *testing.T, name string, seen map[K]V, indexes ...int) {
+	t.Helper()
+
+	if len(seen) != len(indexes) {
+		t.Errorf("%s: expected %v entries, got %v", name, len(indexes), len(seen))
+	}
+
+	for _, i := range indexes {
+		if v, ok := seen[s.Key(i)]; !ok || !s.equal(v, s.Value(i)) {
+			t.Errorf("%s: wrong entry for %v", name, s.Key(i))
+		}
+	}
+}
+
+type ctxKey struct{}
+
+func (s Suite[K, V]) testContext(t *testing.T) {
+	st := s.store(t)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, t.Name())
+	err := st.View(ctx, func(tx behold.Tx[K, V]) error {
+		if tx.Context().Value(ctxKey{}) != t.Name() {
+			t.Error("Tx.Context doesn't derive from the given context")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("View: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fn := func(behold.Tx[K, V]) error {
+		t.Error("transaction started with a cancelled context")
+		return nil
+	}
+
+	if err := st.View(cancelled, fn); !errors.Is(err, context.Canceled) {
+		t.Errorf("View: expected context.Canceled, got %v", err)
+	}
+	if err := st.Update(cancelled, fn); !errors.Is(err, context.Canceled) {
+		t.Errorf("Update: expected context.Canceled, got %v", err)
+	}
+}
+
+func (s Suite[K, V]) testLocks(t *testing.T) {
+	st := s.store(t)
+
+	var mu sync.Mutex
+	var rw sync.RWMutex
+
+	check := func(behold.Tx[K, V]) error {
+		if mu.TryLock() {
+			mu.Unlock()
+			t.Error("lock not held during the transaction")
+		}
+		if rw.TryLock() {
+			rw.Unlock()
+			t.Error("read lock not held during the transaction")
+		}
+		return nil
+	}
+
+	if err := st.View(context.Background(), check, &mu, behold.ROMutex(&rw)); err != nil {
+		t.Errorf("View: %v", err)
+	}
+	if err := st.Update(context.Background(), check, &mu, behold.ROMutex(&rw)); err != nil {
+		t.Errorf("Update: %v", err)
+	}
+
+	if !mu.TryLock() || !rw.TryLock() {
+		t.Error("locks not released after the transactions")
+	}
+}
+
+func (s Suite[K, V]) testConcurrency(t *testing.T) {
+	const writers = 8
+	const readers = 4
+
+	st := s.store(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers+readers)
+
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- st.Update(context.Background(), func(tx behold.Tx[K, V]) error {
+				return tx.Set(s.Key(i), s.Value(i))
+			})
+		}(i)
+	}
+
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- st.View(context.Background(), func(tx behold.Tx[K, V]) error {
+				return tx.ForEach(func(K, V) bool { return true })
+			})
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent transaction: %v", err)
+		}
+	}
+
+	s.view(t, st, func(tx behold.Tx[K, V]) {
+		for i := 0; i < writers; i++ {
+			s.expect(t, tx, i, s.Value(i), true)
+		}
+	})
+}
+
+func (s Suite[K, V]) testClose(t *testing.T) {
+	st := s.store(t)
+	s.fill(t, st, 1)
+
+	if err := st.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fn := func(behold.Tx[K, V]) error { return nil }
+	if err := st.View(context.Background(), fn); !errors.Is(err, behold.ErrClosed) {
+		t.Errorf("View: expected ErrClosed, got %v", err)
+	}
+	if err := st.Update(context.Background(), fn); !errors.Is(err, behold.ErrClosed) {
+		t.Errorf("Update: expected ErrClosed, got %v", err)
+	}
+}
@@ -39,7 +39,8 @@ type Store[K comparable, V any] interface {
 //   - K comparable: The key type, matching the store's key type
 //   - V any: The value type, matching the store's value type
 type Tx[K comparable, V any] interface {
-	// Context returns the transaction's context
+	// Context returns the transaction's context. Operations fail with
+	// its error once it ends, rolling back read-write transactions.
 	Context() context.Context
 
 	// Version returns the data version accessed by this transaction.
@@ -51,19 +52,34 @@ type Tx[K comparable, V any] interface {
 	// ForEach iterates through key-value pairs matching optional queries
 	// The provided function is called for entries matching any of the queries,
 	// or for every entry if no queries are provided.
+	// Queries receive the value of the entry as argument, ForEachMatch
+	// provides the same without giving up type safety.
 	// Iteration can be ended early by returning false from the callback.
 	ForEach(fn func(key K, value V) bool, ors ...Query[any]) error
 
-	// Get retrieves a value by key
+	// ForEachMatch iterates through key-value pairs whose value matches
+	// any of the queries, or every entry if no queries are provided.
+	// Iteration can be ended early by returning false from the callback.
+	ForEachMatch(fn func(key K, value V) bool, ors ...Query[V]) error
+
+	// ForEachEntry iterates through key-value pairs matching any of the
+	// Entry queries, or every entry if no queries are provided.
+	// Iteration can be ended early by returning false from the callback.
+	ForEachEntry(fn func(key K, value V) bool, ors ...Query[Entry[K, V]]) error
+
+	// Get retrieves a value by key.
+	// If the key doesn't exist it fails with a KeyError wrapping ErrNotFound.
 	Get(key K) (value V, err error)
 
 	// Set associates a value with a key
 	Set(key K, value V) error
 
-	// Append adds a value to an existing key (implementation depends on value type)
+	// Append adds a value to an existing key (implementation depends on value type),
+	// or sets it if the key doesn't exist.
 	Append(key K, value V) error
 
-	// Delete removes a key-value pair
+	// Delete removes a key-value pair.
+	// If the key doesn't exist it fails with a KeyError wrapping ErrNotFound.
 	Delete(key K) error
 
 	// Commit persists changes made within the transaction
@@ -72,3 +88,87 @@ type Tx[K comparable, V any] interface {
 	// Close aborts the transaction if not already committed
 	Close() error
 }
+
+// BeginStore is a Store able to start transactions handled by the
+// caller, instead of running them within View or Update.
+//
+// Type Parameters:
+//   - K comparable: The key type, matching the store's key type
+//   - V any: The value type, matching the store's value type
+type BeginStore[K comparable, V any] interface {
+	Store[K, V]
+
+	// Begin starts a transaction, read-write if writable is set, holding
+	// the given locks until it ends. The caller must end it by calling
+	// Commit or Close, usually deferring Close.
+	Begin(ctx context.Context, writable bool, locks ...Mutex) (Tx[K, V], error)
+}
+
+// SnapshotStore is a Store able to run read-only transactions on
+// past versions of its data, as long as it still keeps them.
+//
+// Type Parameters:
+//   - K comparable: The key type, matching the store's key type
+//   - V any: The value type, matching the store's value type
+type SnapshotStore[K comparable, V any] interface {
+	Store[K, V]
+
+	// ViewAt executes a read-only transaction on the data as of the
+	// given version, holding the given locks while fn runs.
+	// It fails with ErrSnapshotExpired if the version is no longer kept,
+	// or ErrInvalid if it hasn't been committed yet.
+	ViewAt(ctx context.Context, version uint64, fn func(Tx[K, V]) error, locks ...Mutex) error
+}
+
+// VersionedTx is a Tx tracking the version each key was last changed
+// at, allowing optimistic concurrency. Committing a read-write VersionedTx
+// fails with a KeyError wrapping ErrConflict if any key it read was
+// changed by another transaction committed after it started.
+//
+// Type Parameters:
+//   - K comparable: The key type, matching the store's key type
+//   - V any: The value type, matching the store's value type
+type VersionedTx[K comparable, V any] interface {
+	Tx[K, V]
+
+	// GetWithVersion retrieves a value by key, together with the version
+	// of the Update that last changed it.
+	// If the key doesn't exist it fails with a KeyError wrapping ErrNotFound.
+	GetWithVersion(key K) (value V, version uint64, err error)
+
+	// SetIfVersion associates a value with a key if the key was last
+	// changed at the given version, or doesn't exist if the version is 0.
+	// Otherwise it fails with a KeyError wrapping ErrConflict.
+	SetIfVersion(key K, value V, version uint64) error
+}
+
+// OrderedTx is a Tx whose entries are sorted by key, allowing range scans.
+// ForEach and its variants visit the entries of an OrderedTx in ascending
+// key order.
+//
+// Type Parameters:
+//   - K comparable: The key type, matching the store's key type
+//   - V any: The value type, matching the store's value type
+type OrderedTx[K comparable, V any] interface {
+	Tx[K, V]
+
+	// Range iterates in ascending key order through the entries with keys
+	// between from (inclusive) and to (exclusive).
+	// Iteration can be ended early by returning false from the callback.
+	Range(from, to K, fn func(key K, value V) bool) error
+
+	// ReverseRange iterates in descending key order through the entries with
+	// keys between from (inclusive) and to (exclusive).
+	// Iteration can be ended early by returning false from the callback.
+	ReverseRange(from, to K, fn func(key K, value V) bool) error
+
+	// Seek iterates in ascending key order through the entries with keys
+	// not less than the given one.
+	// Iteration can be ended early by returning false from the callback.
+	Seek(key K, fn func(key K, value V) bool) error
+
+	// ReverseSeek iterates in descending key order through the entries with
+	// keys not greater than the given one.
+	// Iteration can be ended early by returning false from the callback.
+	ReverseSeek(key K, fn func(key K, value V) bool) error
+}
@@ -1,6 +1,10 @@
 package behold
 
-import "sync"
+import (
+	"context"
+	"sync"
+	"time"
+)
 
 // Mutex defines a standard interface for mutual exclusion locking mechanisms
 // that support basic locking, unlocking, and non-blocking lock attempts.
@@ -20,6 +24,57 @@ type RWMutex interface {
 	TryRLock() bool
 }
 
+// ContextMutex is a Mutex that can be waited on together with a
+// context.
+type ContextMutex interface {
+	Mutex
+
+	// LockContext acquires the mutex, giving up with the context's
+	// error if it ends first.
+	LockContext(ctx context.Context) error
+}
+
+// LockContext acquires the mutex, giving up with the context's error if
+// it ends first. Contexts that never end simply Lock, and a ContextMutex
+// is waited on directly. Otherwise, as a Mutex can't be waited on together
+// with the context, TryLock is retried with a growing delay.
+func LockContext(ctx context.Context, m Mutex) error {
+	switch {
+	case ctx == nil, m == nil:
+		return ErrInvalid
+	case ctx.Done() == nil:
+		m.Lock()
+		return nil
+	case m.TryLock():
+		return nil
+	}
+
+	if cm, ok := m.(ContextMutex); ok {
+		return cm.LockContext(ctx)
+	}
+	return pollLock(ctx, m)
+}
+
+func pollLock(ctx context.Context, m Mutex) error {
+	delay := 50 * time.Microsecond
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			if m.TryLock() {
+				return nil
+			}
+
+			delay = min(2*delay, 10*time.Millisecond)
+			t.Reset(delay)
+		}
+	}
+}
+
 // ROMutex converts an RWMutex to a read-only Mutex, allowing only read locking operations.
 // If the input mutex is nil, it returns nil.
 func ROMutex(m RWMutex) Mutex {
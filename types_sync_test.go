@@ -0,0 +1,71 @@
+package behold
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockContext(t *testing.T) {
+	var mu sync.Mutex
+	ctx := context.Background()
+
+	assert.NoError(t, LockContext(ctx, &mu))
+
+	// released while waiting
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		mu.Unlock()
+	}()
+	assert.NoError(t, LockContext(ctx, &mu))
+
+	// held until the deadline
+	ctx2, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, LockContext(ctx2, &mu), context.DeadlineExceeded)
+
+	var rw sync.RWMutex
+	rw.Lock()
+	assert.ErrorIs(t, LockContext(ctx2, ROMutex(&rw)), context.DeadlineExceeded)
+	rw.Unlock()
+	assert.NoError(t, LockContext(ctx, ROMutex(&rw)))
+
+	assert.ErrorIs(t, LockContext(ctx, nil), ErrInvalid)
+
+	// waited on directly
+	cm := &chanMutex{ch: make(chan struct{}, 1)}
+	cm.Lock()
+	assert.ErrorIs(t, LockContext(ctx2, cm), context.DeadlineExceeded)
+	assert.Equal(t, 1, cm.waits)
+}
+
+// chanMutex is a ContextMutex counting the calls to LockContext.
+type chanMutex struct {
+	ch    chan struct{}
+	waits int
+}
+
+func (m *chanMutex) Lock()   { m.ch <- struct{}{} }
+func (m *chanMutex) Unlock() { <-m.ch }
+
+func (m *chanMutex) TryLock() bool {
+	select {
+	case m.ch <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *chanMutex) LockContext(ctx context.Context) error {
+	m.waits++
+	select {
+	case m.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}